@@ -1,21 +1,262 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/frederickmarvel/supernova/internal/client"
 	"github.com/frederickmarvel/supernova/internal/config"
 	"github.com/frederickmarvel/supernova/internal/db"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+	"github.com/frederickmarvel/supernova/internal/leader"
+	"github.com/frederickmarvel/supernova/internal/migrate"
 	"github.com/frederickmarvel/supernova/internal/router"
+	"github.com/frederickmarvel/supernova/internal/scheduler"
+	"github.com/frederickmarvel/supernova/internal/service"
+	"github.com/frederickmarvel/supernova/internal/store"
 )
 
+const usage = `usage: service [command]
+
+commands:
+  serve      run the HTTP server (default)
+  backfill   rebuild trend history from historical klines
+  migrate    apply or revert schema migrations (up, down [-steps n], status)
+`
+
 func main() {
+	cmd := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
 	cfg := config.Load()
-	database := db.New(cfg)
-	r := router.New(database)
+	switch cmd {
+	case "serve":
+		serve(cfg)
+	case "backfill":
+		backfill(cfg, args)
+	case "migrate":
+		migrateCmd(cfg, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// setup applies indicator and interval configuration and opens the configured
+// store with the symbol registry initialised. The returned database is nil
+// for the in-memory store; otherwise it has been migrated (unless disabled).
+func setup(cfg *config.Config) (*sql.DB, *service.Service) {
+	if err := indicator.RegisterProfiles(cfg.EWMAProfiles); err != nil {
+		log.Fatalf("indicator config error %v", err)
+	}
+	if len(cfg.TrendIndicators) > 0 {
+		if err := indicator.Validate(cfg.TrendIndicators); err != nil {
+			log.Fatalf("indicator config error %v", err)
+		}
+		service.DefaultIndicators = cfg.TrendIndicators
+	}
+	if len(cfg.TrendIntervals) > 0 {
+		for _, iv := range cfg.TrendIntervals {
+			if _, err := client.IntervalDuration(iv); err != nil {
+				log.Fatalf("interval config error %v", err)
+			}
+		}
+		service.Intervals = cfg.TrendIntervals
+	}
+	for _, iv := range cfg.StreamIntervals {
+		if _, err := client.IntervalDuration(iv); err != nil {
+			log.Fatalf("stream interval config error %v", err)
+		}
+	}
+	var database *sql.DB
+	var st store.Store
+	switch cfg.StoreDriver {
+	case "memory":
+		st = store.NewMemory()
+	case "postgres":
+		database = db.New(cfg)
+		if cfg.MigrateOnStart {
+			applied, err := migrate.Up(context.Background(), database)
+			if err != nil {
+				log.Fatalf("migration error %v", err)
+			}
+			for _, m := range applied {
+				log.Printf("applied migration %d_%s", m.Version, m.Name)
+			}
+		}
+		st = store.NewPostgres(database)
+	default:
+		log.Fatalf("unknown STORE_DRIVER %q", cfg.StoreDriver)
+	}
+	svc := service.New(st,
+		client.NewBinanceClient(cfg.BinanceURL, nil),
+		client.NewPublicClient(cfg.IndodaxURL, nil),
+	)
+	if err := svc.InitSymbols(context.Background(), cfg.TrendSymbols); err != nil {
+		log.Fatalf("symbol registry error %v", err)
+	}
+	return database, svc
+}
+
+func serve(cfg *config.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	database, svc := setup(cfg)
+	var sched *scheduler.Scheduler
+	var elector *leader.Elector
+	if cfg.SchedulerEnabled {
+		sched = newScheduler(cfg, svc)
+		if cfg.LeaderElection && database != nil {
+			elector = leader.New(database, leader.KeyFor(cfg.LeaderLock), cfg.LeaderInterval)
+			go elector.Run(ctx)
+			sched.SetGate(elector.IsLeader)
+		}
+		sched.Start(ctx)
+	}
+	for _, iv := range cfg.StreamIntervals {
+		go func(iv string) {
+			if err := svc.Watch(ctx, iv); err != nil {
+				log.Printf("kline stream %s: %v", iv, err)
+			}
+		}(iv)
+	}
+	r := router.New(svc, sched)
+	srv := &http.Server{Addr: ":8000", Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
 
 	log.Println("starting server on port 8000")
-	if err := http.ListenAndServe(":8000", r); err != nil {
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
+	if elector != nil {
+		// wait for Run to release the advisory lock
+		<-elector.Done()
+	}
+}
+
+// newScheduler adds an update job for every configured interval and for any
+// interval that only appears in TREND_SCHEDULE.
+func newScheduler(cfg *config.Config, svc *service.Service) *scheduler.Scheduler {
+	sched := scheduler.New(cfg.SchedulerJitter)
+	intervals := append([]string{}, service.Intervals...)
+	for iv := range cfg.TrendSchedules {
+		if !slices.Contains(intervals, iv) {
+			intervals = append(intervals, iv)
+		}
+	}
+	for _, iv := range intervals {
+		job, err := svc.UpdateJob(iv, cfg.TrendSchedules[iv], cfg.SchedulerDelay)
+		if err != nil {
+			log.Fatalf("scheduler config error %v", err)
+		}
+		sched.Add(job)
+		log.Printf("scheduled %s at %q", job.Name, job.Schedule)
+	}
+	return sched
+}
+
+func backfill(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	symbols := fs.String("symbols", "", "comma-separated names or symbols (default: all registered)")
+	interval := fs.String("interval", service.DefaultInterval, "kline interval")
+	from := fs.String("from", "", "start date, YYYY-MM-DD or RFC3339 (required)")
+	to := fs.String("to", "", "end date, YYYY-MM-DD or RFC3339 (default: now)")
+	fs.Parse(args)
+
+	opts := service.BackfillOptions{Interval: *interval}
+	if *symbols != "" {
+		opts.Symbols = strings.Split(*symbols, ",")
+	}
+	var err error
+	if opts.From, err = parseDate(*from); err != nil || opts.From.IsZero() {
+		log.Fatalf("backfill: invalid -from %q", *from)
+	}
+	if opts.To, err = parseDate(*to); err != nil {
+		log.Fatalf("backfill: invalid -to %q", *to)
+	}
+
+	_, svc := setup(cfg)
+	n, err := svc.Backfill(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("backfill error after %d readings: %v", n, err)
+	}
+	log.Printf("backfill done: %d readings", n)
+}
+
+func migrateCmd(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("migrate "+sub, flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	fs.Parse(args[1:])
+
+	ctx := context.Background()
+	database := db.New(cfg)
+	switch sub {
+	case "up":
+		applied, err := migrate.Up(ctx, database)
+		if err != nil {
+			log.Fatalf("migrate up: %v", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		reverted, err := migrate.Down(ctx, database, *steps)
+		if err != nil {
+			log.Fatalf("migrate down: %v", err)
+		}
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		list, err := migrate.List(ctx, database)
+		if err != nil {
+			log.Fatalf("migrate status: %v", err)
+		}
+		for _, st := range list {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-32s %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
 }
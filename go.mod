@@ -9,4 +9,4 @@ require (
 	github.com/lib/pq v1.10.9
 )
 
-require github.com/shopspring/decimal v1.4.0 // indirect
+require github.com/shopspring/decimal v1.4.0
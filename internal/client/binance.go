@@ -1,57 +1,283 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+const binanceURL = "https://api.binance.com"
+
+// Kline represents one Binance candle. Prices and volumes keep the exact
+// decimal values Binance sends; the float accessors are for indicator math.
 type Kline struct {
-	OpenTime  int64
-	Open      string
-	High      string
-	Low       string
-	Close     string
-	Volume    string
-	CloseTime int64
-}
-
-// Fetch last 180 days of daily klines
-func FetchKlines(symbol string) ([]Kline, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, _ := http.NewRequest(
-		http.MethodGet,
-		"https://api.binance.com/api/v3/klines",
-		nil,
-	)
-	q := req.URL.Query()
-	q.Add("symbol", symbol)
-	q.Add("interval", "1d")
-	q.Add("limit", "180")
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var raw [][]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, err
-	}
-
-	klines := make([]Kline, len(raw))
-	for i, entry := range raw {
-		klines[i] = Kline{
-			OpenTime:  int64(entry[0].(float64)),
-			Open:      entry[1].(string),
-			High:      entry[2].(string),
-			Low:       entry[3].(string),
-			Close:     entry[4].(string),
-			Volume:    entry[5].(string),
-			CloseTime: int64(entry[6].(float64)),
-		}
-	}
-	return klines, nil
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      decimal.Decimal
+	High      decimal.Decimal
+	Low       decimal.Decimal
+	Close     decimal.Decimal
+	// Volume is in the base asset, QuoteVolume in the quote asset.
+	Volume              decimal.Decimal
+	QuoteVolume         decimal.Decimal
+	Trades              int64
+	TakerBuyBaseVolume  decimal.Decimal
+	TakerBuyQuoteVolume decimal.Decimal
+}
+
+func (k Kline) OpenFloat() float64   { return k.Open.InexactFloat64() }
+func (k Kline) HighFloat() float64   { return k.High.InexactFloat64() }
+func (k Kline) LowFloat() float64    { return k.Low.InexactFloat64() }
+func (k Kline) CloseFloat() float64  { return k.Close.InexactFloat64() }
+func (k Kline) VolumeFloat() float64 { return k.Volume.InexactFloat64() }
+
+// parseKline decodes one row of the klines endpoint:
+// [openTime, open, high, low, close, volume, closeTime, quoteVolume, trades,
+// takerBuyBase, takerBuyQuote, ignore].
+func parseKline(row []json.RawMessage) (Kline, error) {
+	if len(row) < 11 {
+		return Kline{}, fmt.Errorf("kline has %d fields, want at least 11", len(row))
+	}
+	var k Kline
+	var openMs, closeMs int64
+	ints := []struct {
+		name string
+		i    int
+		dst  *int64
+	}{
+		{"open time", 0, &openMs},
+		{"close time", 6, &closeMs},
+		{"trades", 8, &k.Trades},
+	}
+	for _, f := range ints {
+		if err := json.Unmarshal(row[f.i], f.dst); err != nil {
+			return Kline{}, fmt.Errorf("kline %s: %w", f.name, err)
+		}
+	}
+	decimals := []struct {
+		name string
+		i    int
+		dst  *decimal.Decimal
+	}{
+		{"open", 1, &k.Open},
+		{"high", 2, &k.High},
+		{"low", 3, &k.Low},
+		{"close", 4, &k.Close},
+		{"volume", 5, &k.Volume},
+		{"quote volume", 7, &k.QuoteVolume},
+		{"taker buy base volume", 9, &k.TakerBuyBaseVolume},
+		{"taker buy quote volume", 10, &k.TakerBuyQuoteVolume},
+	}
+	for _, f := range decimals {
+		var raw string
+		if err := json.Unmarshal(row[f.i], &raw); err != nil {
+			return Kline{}, fmt.Errorf("kline %d %s: %w", openMs, f.name, err)
+		}
+		d, err := decimal.NewFromString(raw)
+		if err != nil {
+			return Kline{}, fmt.Errorf("kline %d %s: %w", openMs, f.name, err)
+		}
+		*f.dst = d
+	}
+	if closeMs < openMs {
+		return Kline{}, fmt.Errorf("kline %d closes before it opens", openMs)
+	}
+	k.OpenTime = time.UnixMilli(openMs).UTC()
+	k.CloseTime = time.UnixMilli(closeMs).UTC()
+	return k, nil
+}
+
+var intervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  72 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// IntervalDuration returns the candle length of a Binance kline interval.
+// Calendar-month candles ("1M") are not supported.
+func IntervalDuration(interval string) (time.Duration, error) {
+	d, ok := intervals[interval]
+	if !ok {
+		return 0, fmt.Errorf("unsupported kline interval %q", interval)
+	}
+	return d, nil
+}
+
+// BinanceError is a non-200 reply from the Binance REST API.
+type BinanceError struct {
+	HTTPStatus int
+	Code       int    `json:"code"`
+	Message    string `json:"msg"`
+	// RetryAfter is set for 418 and 429 replies.
+	RetryAfter time.Duration
+}
+
+func (e *BinanceError) Error() string {
+	return fmt.Sprintf("binance: http %d: %d %s", e.HTTPStatus, e.Code, e.Message)
+}
+
+// BinanceClient calls the public Binance REST API. It follows the request
+// weight Binance reports in X-MBX-USED-WEIGHT-1M, waits for the next minute
+// instead of exceeding WeightLimit, and backs off for the Retry-After period
+// after a 429 or 418.
+type BinanceClient struct {
+	baseURL    string
+	httpClient *http.Client
+	// WeightLimit is the request weight allowed per minute.
+	WeightLimit int
+	// MaxRetries bounds how often a 429 is retried after its Retry-After.
+	MaxRetries int
+
+	mu           sync.Mutex
+	usedWeight   int
+	weightMinute time.Time
+	blockedUntil time.Time
+	banned       bool
+}
+
+// NewBinanceClient returns a client for baseURL, or api.binance.com when it
+// is empty. A nil client gets a default with a 10s timeout.
+func NewBinanceClient(baseURL string, client *http.Client) *BinanceClient {
+	if baseURL == "" {
+		baseURL = binanceURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &BinanceClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  client,
+		WeightLimit: 6000,
+		MaxRetries:  2,
+	}
+}
+
+// UsedWeight returns the request weight used in the current minute, as last
+// reported by Binance.
+func (c *BinanceClient) UsedWeight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Truncate(time.Minute).After(c.weightMinute) {
+		return 0
+	}
+	return c.usedWeight
+}
+
+// reserve waits until a request of the given weight may be sent. An IP ban
+// (418) is not waited out; the error is returned until it expires.
+func (c *BinanceClient) reserve(ctx context.Context, weight int) error {
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		switch {
+		case now.Before(c.blockedUntil):
+			if c.banned {
+				until := c.blockedUntil
+				c.mu.Unlock()
+				return fmt.Errorf("binance: banned until %s", until.Format(time.RFC3339))
+			}
+			wait = c.blockedUntil.Sub(now)
+		case now.Truncate(time.Minute).After(c.weightMinute):
+			c.weightMinute = now.Truncate(time.Minute)
+			c.usedWeight = 0
+		case c.usedWeight+weight > c.WeightLimit:
+			wait = c.weightMinute.Add(time.Minute).Sub(now)
+		}
+		if wait == 0 {
+			// count the request now so concurrent callers see it before
+			// the response header arrives
+			c.usedWeight += weight
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// observe records the weight and back-off headers of a response.
+func (c *BinanceClient) observe(resp *http.Response) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if w, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		c.weightMinute = time.Now().Truncate(time.Minute)
+		c.usedWeight = w
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+		return 0
+	}
+	retry := time.Minute
+	if sec, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retry = time.Duration(sec) * time.Second
+	}
+	if until := time.Now().Add(retry); until.After(c.blockedUntil) {
+		c.blockedUntil = until
+	}
+	c.banned = resp.StatusCode == http.StatusTeapot
+	return retry
+}
+
+// get sends a weighted GET request to path and decodes the JSON reply into
+// out, retrying rate-limited requests after Retry-After.
+func (c *BinanceClient) get(ctx context.Context, path string, q url.Values, weight int, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.reserve(ctx, weight); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("do request: %w", err)
+		}
+		retry := c.observe(resp)
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			apiErr := &BinanceError{HTTPStatus: resp.StatusCode, RetryAfter: retry}
+			if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+				apiErr.Message = strings.TrimSpace(string(data))
+			}
+			if resp.StatusCode == http.StatusTooManyRequests && attempt < c.MaxRetries {
+				continue
+			}
+			return apiErr
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+		return nil
+	}
 }
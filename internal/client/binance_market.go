@@ -0,0 +1,301 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// codeInvalidSymbol is the Binance error code for an unknown symbol.
+const codeInvalidSymbol = -1121
+
+// ErrInvalidSymbol is returned for symbols Binance does not list.
+var ErrInvalidSymbol = errors.New("binance: invalid symbol")
+
+// UnixMilli is a time decoded from a Binance millisecond timestamp.
+type UnixMilli struct {
+	time.Time
+}
+
+func (t *UnixMilli) UnmarshalJSON(b []byte) error {
+	var ms int64
+	if err := json.Unmarshal(b, &ms); err != nil {
+		return fmt.Errorf("timestamp: %w", err)
+	}
+	t.Time = time.UnixMilli(ms).UTC()
+	return nil
+}
+
+func (t UnixMilli) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Time)
+}
+
+// ExchangeInfo represents the response from the exchangeInfo endpoint
+type ExchangeInfo struct {
+	Timezone   string       `json:"timezone"`
+	ServerTime UnixMilli    `json:"serverTime"`
+	RateLimits []RateLimit  `json:"rateLimits"`
+	Symbols    []SymbolInfo `json:"symbols"`
+}
+
+// RateLimit represents one request, order or raw-request limit
+type RateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+// SymbolInfo represents the trading rules of a single symbol
+type SymbolInfo struct {
+	Symbol              string         `json:"symbol"`
+	Status              string         `json:"status"`
+	BaseAsset           string         `json:"baseAsset"`
+	BaseAssetPrecision  int            `json:"baseAssetPrecision"`
+	QuoteAsset          string         `json:"quoteAsset"`
+	QuoteAssetPrecision int            `json:"quoteAssetPrecision"`
+	OrderTypes          []string       `json:"orderTypes"`
+	SpotTradingAllowed  bool           `json:"isSpotTradingAllowed"`
+	Filters             []SymbolFilter `json:"filters"`
+}
+
+// SymbolFilter represents one entry of a symbol's filters. Only the fields
+// belonging to FilterType are set.
+type SymbolFilter struct {
+	FilterType string `json:"filterType"`
+	// PRICE_FILTER
+	MinPrice decimal.Decimal `json:"minPrice"`
+	MaxPrice decimal.Decimal `json:"maxPrice"`
+	TickSize decimal.Decimal `json:"tickSize"`
+	// LOT_SIZE and MARKET_LOT_SIZE
+	MinQty   decimal.Decimal `json:"minQty"`
+	MaxQty   decimal.Decimal `json:"maxQty"`
+	StepSize decimal.Decimal `json:"stepSize"`
+	// NOTIONAL and MIN_NOTIONAL
+	MinNotional decimal.Decimal `json:"minNotional"`
+	MaxNotional decimal.Decimal `json:"maxNotional"`
+	// MAX_NUM_ORDERS, MAX_NUM_ALGO_ORDERS
+	MaxNumOrders     int `json:"maxNumOrders"`
+	MaxNumAlgoOrders int `json:"maxNumAlgoOrders"`
+}
+
+// Trading reports whether the symbol is open for trading.
+func (s SymbolInfo) Trading() bool {
+	return s.Status == "TRADING"
+}
+
+// Filter returns the filter of the given type, such as "LOT_SIZE".
+func (s SymbolInfo) Filter(filterType string) (SymbolFilter, bool) {
+	for _, f := range s.Filters {
+		if f.FilterType == filterType {
+			return f, true
+		}
+	}
+	return SymbolFilter{}, false
+}
+
+// TickSize returns the price increment, or zero when prices are unrestricted.
+func (s SymbolInfo) TickSize() decimal.Decimal {
+	f, _ := s.Filter("PRICE_FILTER")
+	return f.TickSize
+}
+
+// StepSize returns the quantity increment, or zero when unrestricted.
+func (s SymbolInfo) StepSize() decimal.Decimal {
+	f, _ := s.Filter("LOT_SIZE")
+	return f.StepSize
+}
+
+// Ticker24h represents the rolling 24 hour statistics of a symbol
+type Ticker24h struct {
+	Symbol             string          `json:"symbol"`
+	PriceChange        decimal.Decimal `json:"priceChange"`
+	PriceChangePercent decimal.Decimal `json:"priceChangePercent"`
+	WeightedAvgPrice   decimal.Decimal `json:"weightedAvgPrice"`
+	PrevClosePrice     decimal.Decimal `json:"prevClosePrice"`
+	LastPrice          decimal.Decimal `json:"lastPrice"`
+	LastQty            decimal.Decimal `json:"lastQty"`
+	BidPrice           decimal.Decimal `json:"bidPrice"`
+	BidQty             decimal.Decimal `json:"bidQty"`
+	AskPrice           decimal.Decimal `json:"askPrice"`
+	AskQty             decimal.Decimal `json:"askQty"`
+	OpenPrice          decimal.Decimal `json:"openPrice"`
+	HighPrice          decimal.Decimal `json:"highPrice"`
+	LowPrice           decimal.Decimal `json:"lowPrice"`
+	Volume             decimal.Decimal `json:"volume"`
+	QuoteVolume        decimal.Decimal `json:"quoteVolume"`
+	OpenTime           UnixMilli       `json:"openTime"`
+	CloseTime          UnixMilli       `json:"closeTime"`
+	FirstID            int64           `json:"firstId"`
+	LastID             int64           `json:"lastId"`
+	Count              int64           `json:"count"`
+}
+
+// BookTicker represents the best bid and ask of a symbol
+type BookTicker struct {
+	Symbol   string          `json:"symbol"`
+	BidPrice decimal.Decimal `json:"bidPrice"`
+	BidQty   decimal.Decimal `json:"bidQty"`
+	AskPrice decimal.Decimal `json:"askPrice"`
+	AskQty   decimal.Decimal `json:"askQty"`
+}
+
+// PriceLevel represents one [price, quantity] entry of an order book
+type PriceLevel struct {
+	Price decimal.Decimal
+	Qty   decimal.Decimal
+}
+
+func (l *PriceLevel) UnmarshalJSON(b []byte) error {
+	var pair []decimal.Decimal
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return fmt.Errorf("price level: %w", err)
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("price level has %d fields, want 2", len(pair))
+	}
+	l.Price, l.Qty = pair[0], pair[1]
+	return nil
+}
+
+// Depth represents an order book snapshot
+type Depth struct {
+	LastUpdateID int64        `json:"lastUpdateId"`
+	Bids         []PriceLevel `json:"bids"`
+	Asks         []PriceLevel `json:"asks"`
+}
+
+// AggTrade represents trades filled at the same time, price and side
+type AggTrade struct {
+	ID           int64           `json:"a"`
+	Price        decimal.Decimal `json:"p"`
+	Qty          decimal.Decimal `json:"q"`
+	FirstTradeID int64           `json:"f"`
+	LastTradeID  int64           `json:"l"`
+	Time         UnixMilli       `json:"T"`
+	BuyerMaker   bool            `json:"m"`
+	BestMatch    bool            `json:"M"`
+}
+
+// wrapSymbolErr maps Binance's invalid-symbol reply to ErrInvalidSymbol.
+func wrapSymbolErr(err error, symbols ...string) error {
+	var apiErr *BinanceError
+	if errors.As(err, &apiErr) && apiErr.Code == codeInvalidSymbol {
+		return fmt.Errorf("%w: %v", ErrInvalidSymbol, symbols)
+	}
+	return err
+}
+
+// ExchangeInfo returns the trading rules of the given symbols, or of every
+// symbol when none are given. Binance rejects the whole request if any
+// symbol is unknown.
+func (c *BinanceClient) ExchangeInfo(ctx context.Context, symbols ...string) (*ExchangeInfo, error) {
+	q := url.Values{}
+	switch len(symbols) {
+	case 0:
+	case 1:
+		q.Set("symbol", symbols[0])
+	default:
+		list, _ := json.Marshal(symbols)
+		q.Set("symbols", string(list))
+	}
+	var res ExchangeInfo
+	if err := c.get(ctx, "/api/v3/exchangeInfo", q, ExchangeInfoWeight, &res); err != nil {
+		return nil, wrapSymbolErr(fmt.Errorf("exchangeInfo: %w", err), symbols...)
+	}
+	return &res, nil
+}
+
+// Symbol returns the trading rules of one symbol.
+func (c *BinanceClient) Symbol(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	info, err := c.ExchangeInfo(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range info.Symbols {
+		if s.Symbol == symbol {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidSymbol, symbol)
+}
+
+func (c *BinanceClient) Ticker24h(ctx context.Context, symbol string) (*Ticker24h, error) {
+	var res Ticker24h
+	q := url.Values{"symbol": {symbol}}
+	if err := c.get(ctx, "/api/v3/ticker/24hr", q, Ticker24hWeight, &res); err != nil {
+		return nil, wrapSymbolErr(fmt.Errorf("ticker 24h: %w", err), symbol)
+	}
+	return &res, nil
+}
+
+func (c *BinanceClient) BookTicker(ctx context.Context, symbol string) (*BookTicker, error) {
+	var res BookTicker
+	q := url.Values{"symbol": {symbol}}
+	if err := c.get(ctx, "/api/v3/ticker/bookTicker", q, 2, &res); err != nil {
+		return nil, wrapSymbolErr(fmt.Errorf("book ticker: %w", err), symbol)
+	}
+	return &res, nil
+}
+
+// Depth returns the top limit levels of each side of the order book; Binance
+// accepts limits up to 5000 and defaults to 100 when limit is zero.
+func (c *BinanceClient) Depth(ctx context.Context, symbol string, limit int) (*Depth, error) {
+	q := url.Values{"symbol": {symbol}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	weight := 5
+	switch {
+	case limit > 1000:
+		weight = 250
+	case limit > 500:
+		weight = 50
+	case limit > 100:
+		weight = 25
+	}
+	var res Depth
+	if err := c.get(ctx, "/api/v3/depth", q, weight, &res); err != nil {
+		return nil, wrapSymbolErr(fmt.Errorf("depth: %w", err), symbol)
+	}
+	return &res, nil
+}
+
+// AggTradesOption narrows an aggTrades request.
+type AggTradesOption func(url.Values)
+
+// AggTradesFromID starts at the aggregate trade with the given ID.
+func AggTradesFromID(id int64) AggTradesOption {
+	return func(q url.Values) { q.Set("fromId", strconv.FormatInt(id, 10)) }
+}
+
+// AggTradesBetween limits trades to a window of at most one hour.
+func AggTradesBetween(start, end time.Time) AggTradesOption {
+	return func(q url.Values) {
+		q.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+		q.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+}
+
+// AggTradesLimit sets the number of trades returned, at most 1000.
+func AggTradesLimit(n int) AggTradesOption {
+	return func(q url.Values) { q.Set("limit", strconv.Itoa(n)) }
+}
+
+func (c *BinanceClient) AggTrades(ctx context.Context, symbol string, opts ...AggTradesOption) ([]AggTrade, error) {
+	q := url.Values{"symbol": {symbol}}
+	for _, opt := range opts {
+		opt(q)
+	}
+	var res []AggTrade
+	if err := c.get(ctx, "/api/v3/aggTrades", q, 4, &res); err != nil {
+		return nil, wrapSymbolErr(fmt.Errorf("aggTrades: %w", err), symbol)
+	}
+	return res, nil
+}
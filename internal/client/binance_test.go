@@ -0,0 +1,77 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/client/binancetest"
+)
+
+const klinesPath = "/api/v3/klines"
+
+func newBinance(t *testing.T) *binancetest.Server {
+	t.Helper()
+	srv := binancetest.NewServer()
+	t.Cleanup(srv.Close)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := srv.SetPath("BTCUSDT", binancetest.Path{
+		Shape: binancetest.Trend, Start: start, Interval: "1h", Count: 48,
+		Price: 40000, Drift: 0.001, Noise: 0.01, Seed: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Now = func() time.Time { return start.Add(48 * time.Hour) }
+	return srv
+}
+
+func TestBinanceRetriesAfterRetryAfter(t *testing.T) {
+	srv := newBinance(t)
+	bn := srv.BinanceClient()
+	srv.Fail(klinesPath, http.StatusTooManyRequests, time.Second)
+
+	start := time.Now()
+	klines, err := bn.Klines(context.Background(), "BTCUSDT", "1h", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 10 {
+		t.Errorf("%d klines, want 10", len(klines))
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, before Retry-After", waited)
+	}
+	if n := srv.Hits(klinesPath); n != 2 {
+		t.Errorf("%d requests, want the 429 and one retry", n)
+	}
+}
+
+func TestBinanceGivesUpAfterMaxRetries(t *testing.T) {
+	srv := newBinance(t)
+	bn := srv.BinanceClient()
+	bn.MaxRetries = 0
+	srv.Fail(klinesPath, http.StatusTooManyRequests, time.Second)
+
+	_, err := bn.Klines(context.Background(), "BTCUSDT", "1h", 10)
+	var apiErr *client.BinanceError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 BinanceError", err)
+	}
+	if apiErr.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want 1s", apiErr.RetryAfter)
+	}
+
+	// the block from Retry-After holds back the next request as well
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := bn.Klines(ctx, "BTCUSDT", "1h", 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("request during the block: err = %v, want it to wait", err)
+	}
+	if n := srv.Hits(klinesPath); n != 1 {
+		t.Errorf("%d requests, want only the 429", n)
+	}
+}
@@ -0,0 +1,97 @@
+package binancetest
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// GoldenStart and GoldenCount place the golden paths: hourly candles from
+// the start of 2024.
+var GoldenStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const GoldenCount = 400
+
+// GoldenPaths are the series indicators are checked against. gap is trend
+// with a day of candles missing, as when a market was halted.
+var GoldenPaths = map[string]Path{
+	"trend":       {Shape: Trend, Drift: 0.002, Noise: 0.01, Seed: 1},
+	"mean_revert": {Shape: MeanRevert, Drift: 0.1, Noise: 0.02, Seed: 2},
+	"crash":       {Shape: Crash, Drift: 0.3, Noise: 0.005, At: 350, Seed: 3},
+	"gap":         {Shape: Trend, Drift: 0.002, Noise: 0.01, Seed: 1},
+}
+
+// GoldenEnd is when the last golden candle closes.
+func GoldenEnd() time.Time {
+	return GoldenStart.Add(GoldenCount * time.Hour)
+}
+
+// GoldenKlines generates the hourly klines of the named golden path.
+func GoldenKlines(name string) ([]client.Kline, error) {
+	p := GoldenPaths[name]
+	p.Start, p.Interval, p.Count, p.Price = GoldenStart, "1h", GoldenCount, 100
+	klines, err := Generate(p)
+	if err != nil {
+		return nil, err
+	}
+	if name == "gap" {
+		klines = WithGap(klines, 300, 24)
+	}
+	return klines, nil
+}
+
+// Golden compares got, keyed by series, indicator and output, with
+// testdata/name, or rewrites the file when the test runs with -update.
+func Golden(t testing.TB, name string, got map[string]map[string]map[string]float64) {
+	t.Helper()
+	file := filepath.Join("testdata", name)
+	if *update {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v; run go test -update to create it", err)
+	}
+	var want map[string]map[string]map[string]float64
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	for k, inds := range got {
+		for ind, outputs := range inds {
+			for out, v := range outputs {
+				w, ok := want[k][ind][out]
+				if !ok {
+					t.Errorf("%s %s %s = %v, not in %s", k, ind, out, v, file)
+					continue
+				}
+				if math.Abs(v-w) > 1e-9*math.Max(1, math.Abs(w)) {
+					t.Errorf("%s %s %s = %v, want %v", k, ind, out, v, w)
+				}
+			}
+		}
+	}
+	for k, inds := range want {
+		for ind, outputs := range inds {
+			for out := range outputs {
+				if _, ok := got[k][ind][out]; !ok {
+					t.Errorf("%s %s %s missing", k, ind, out)
+				}
+			}
+		}
+	}
+}
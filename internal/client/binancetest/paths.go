@@ -0,0 +1,140 @@
+package binancetest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/shopspring/decimal"
+)
+
+// Shape selects how a generated price path moves.
+type Shape int
+
+const (
+	// Trend moves the close by Drift per candle.
+	Trend Shape = iota
+	// MeanRevert pulls the close back towards Price by Drift of the
+	// distance each candle, so the series oscillates with the noise.
+	MeanRevert
+	// Crash is flat until candle At, drops by Drift within that candle and
+	// stays flat after it.
+	Crash
+)
+
+// Path describes a deterministic generated kline series. The same Path
+// always yields the same candles.
+type Path struct {
+	Shape    Shape
+	Start    time.Time
+	Interval string
+	Count    int
+	// Price is the first open.
+	Price float64
+	// Drift is a fraction: per candle for Trend, the pull for MeanRevert
+	// and the size of the drop for Crash.
+	Drift float64
+	// Noise is the standard deviation of the per-candle return.
+	Noise float64
+	// At is the crash candle; Count/2 when zero.
+	At   int
+	Seed int64
+}
+
+// places is the precision generated prices and volumes are rounded to.
+const places = 8
+
+// Generate builds the klines of p, oldest first.
+func Generate(p Path) ([]client.Kline, error) {
+	dur, err := client.IntervalDuration(p.Interval)
+	if err != nil {
+		return nil, err
+	}
+	if p.Price <= 0 {
+		return nil, fmt.Errorf("path price must be positive, got %v", p.Price)
+	}
+	at := p.At
+	if at == 0 {
+		at = p.Count / 2
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	klines := make([]client.Kline, 0, p.Count)
+	open := p.Price
+	for i := 0; i < p.Count; i++ {
+		ret := p.Noise * rng.NormFloat64()
+		switch p.Shape {
+		case Trend:
+			ret += p.Drift
+		case MeanRevert:
+			ret += p.Drift * (p.Price - open) / open
+		case Crash:
+			if i == at {
+				ret -= p.Drift
+			}
+		}
+		// keep the price positive whatever the noise
+		closePrice := math.Max(open*(1+ret), open*0.01)
+		wick := math.Abs(p.Noise * rng.NormFloat64() / 2)
+		high := math.Max(open, closePrice) * (1 + wick)
+		low := math.Min(open, closePrice) * (1 - wick)
+		volume := 100 * (1 + rng.Float64())
+		if p.Shape == Crash && i == at {
+			volume *= 10
+		}
+
+		openTime := p.Start.Add(time.Duration(i) * dur).UTC()
+		vol := round(volume)
+		quoteVol := vol.Mul(round(closePrice)).Round(places)
+		klines = append(klines, client.Kline{
+			OpenTime:            openTime,
+			CloseTime:           openTime.Add(dur - time.Millisecond),
+			Open:                round(open),
+			High:                round(high),
+			Low:                 round(low),
+			Close:               round(closePrice),
+			Volume:              vol,
+			QuoteVolume:         quoteVol,
+			Trades:              int64(volume * 3),
+			TakerBuyBaseVolume:  vol.Div(decimal.NewFromInt(2)).Round(places),
+			TakerBuyQuoteVolume: quoteVol.Div(decimal.NewFromInt(2)).Round(places),
+		})
+		open = closePrice
+	}
+	return klines, nil
+}
+
+func round(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f).Round(places)
+}
+
+// WithGap returns klines without the n candles starting at index from, as
+// when a market was halted.
+func WithGap(klines []client.Kline, from, n int) []client.Kline {
+	if from < 0 || from >= len(klines) {
+		return klines
+	}
+	to := from + n
+	if to > len(klines) {
+		to = len(klines)
+	}
+	out := make([]client.Kline, 0, len(klines)-(to-from))
+	out = append(out, klines[:from]...)
+	return append(out, klines[to:]...)
+}
+
+// LoadKlines reads a fixture holding a /api/v3/klines reply, such as one
+// saved with curl.
+func LoadKlines(path string) ([]client.Kline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+	klines, err := client.ParseKlines(data)
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return klines, nil
+}
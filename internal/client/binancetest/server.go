@@ -0,0 +1,424 @@
+// Package binancetest provides a fake Binance REST API serving klines, 24h
+// tickers and exchangeInfo from fixtures or generated price paths, with
+// injectable 429, 418 and 5xx replies, so the trend pipeline can run
+// deterministically offline.
+package binancetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/shopspring/decimal"
+)
+
+// quoteAssets are recognised when a symbol is added without SymbolInfo.
+var quoteAssets = []string{"USDT", "FDUSD", "USDC", "BUSD", "TUSD", "BTC", "ETH", "BNB", "EUR", "TRY", "IDR"}
+
+type fault struct {
+	status     int
+	retryAfter time.Duration
+}
+
+// Server is a fake Binance REST API. Now and WeightLimit may be changed
+// before requests are made; data and faults are changed through methods.
+type Server struct {
+	*httptest.Server
+
+	// Now is the server clock. Klines requested without a time range end
+	// at it, and the 24h ticker covers the day before it.
+	Now func() time.Time
+	// WeightLimit is the request weight per minute served before 429s.
+	WeightLimit int
+
+	mu           sync.Mutex
+	klines       map[string]map[string][]client.Kline
+	symbols      map[string]client.SymbolInfo
+	listed       []string
+	faults       map[string][]fault
+	hits         map[string]int
+	weight       int
+	weightMinute time.Time
+}
+
+// NewServer starts an empty fake. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		Now:         time.Now,
+		WeightLimit: 6000,
+		klines:      make(map[string]map[string][]client.Kline),
+		symbols:     make(map[string]client.SymbolInfo),
+		faults:      make(map[string][]fault),
+		hits:        make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BinanceClient returns a client pointed at the fake.
+func (s *Server) BinanceClient() *client.BinanceClient {
+	return client.NewBinanceClient(s.URL, s.Client())
+}
+
+// AddSymbol lists a symbol in exchangeInfo with the given trading rules.
+func (s *Server) AddSymbol(info client.SymbolInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addSymbol(info)
+}
+
+func (s *Server) addSymbol(info client.SymbolInfo) {
+	if _, ok := s.symbols[info.Symbol]; !ok {
+		s.listed = append(s.listed, info.Symbol)
+	}
+	s.symbols[info.Symbol] = info
+}
+
+// defaultSymbol returns trading rules for a symbol added through its
+// klines alone.
+func defaultSymbol(symbol string) client.SymbolInfo {
+	base, quote := symbol, ""
+	for _, q := range quoteAssets {
+		if strings.HasSuffix(symbol, q) && len(symbol) > len(q) {
+			base, quote = strings.TrimSuffix(symbol, q), q
+			break
+		}
+	}
+	d := decimal.RequireFromString
+	return client.SymbolInfo{
+		Symbol:              symbol,
+		Status:              "TRADING",
+		BaseAsset:           base,
+		BaseAssetPrecision:  8,
+		QuoteAsset:          quote,
+		QuoteAssetPrecision: 8,
+		OrderTypes:          []string{"LIMIT", "MARKET"},
+		SpotTradingAllowed:  true,
+		Filters: []client.SymbolFilter{
+			{FilterType: "PRICE_FILTER", MinPrice: d("0.01"), MaxPrice: d("1000000"), TickSize: d("0.01")},
+			{FilterType: "LOT_SIZE", MinQty: d("0.00001"), MaxQty: d("9000"), StepSize: d("0.00001")},
+		},
+	}
+}
+
+// SetKlines serves klines, oldest first, for symbol and interval, listing
+// the symbol with default rules unless AddSymbol was called for it.
+func (s *Server) SetKlines(symbol, interval string, klines []client.Kline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.symbols[symbol]; !ok {
+		s.addSymbol(defaultSymbol(symbol))
+	}
+	if s.klines[symbol] == nil {
+		s.klines[symbol] = make(map[string][]client.Kline)
+	}
+	s.klines[symbol][interval] = append([]client.Kline(nil), klines...)
+}
+
+// SetPath generates p and serves it for symbol.
+func (s *Server) SetPath(symbol string, p Path) error {
+	klines, err := Generate(p)
+	if err != nil {
+		return err
+	}
+	s.SetKlines(symbol, p.Interval, klines)
+	return nil
+}
+
+// Fail makes the next request to path (such as "/api/v3/klines") answer
+// with status: 429 and 418 with a Retry-After header, any other status as
+// a server error. Faults queue up per path.
+func (s *Server) Fail(path string, status int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = append(s.faults[path], fault{status, retryAfter})
+}
+
+// Hits returns how many requests reached path, faults included.
+func (s *Server) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+type object = map[string]interface{}
+
+// apiError is a Binance error reply.
+type apiError struct {
+	status int
+	code   int
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func badRequest(code int, format string, args ...interface{}) error {
+	return &apiError{status: http.StatusBadRequest, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+var errInvalidSymbol = &apiError{status: http.StatusBadRequest, code: -1121, msg: "Invalid symbol."}
+
+type endpoint struct {
+	weight int
+	fn     func(*Server, url.Values) (interface{}, error)
+}
+
+var endpoints = map[string]endpoint{
+	"/api/v3/klines":       {client.KlinesWeight, (*Server).serveKlines},
+	"/api/v3/ticker/24hr":  {client.Ticker24hWeight, (*Server).serveTicker},
+	"/api/v3/exchangeInfo": {client.ExchangeInfoWeight, (*Server).serveExchangeInfo},
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.Path
+	s.hits[path]++
+
+	if q := s.faults[path]; len(q) > 0 {
+		s.faults[path] = q[1:]
+		s.writeFault(w, q[0])
+		return
+	}
+	ep, ok := endpoints[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.writeJSON(w, http.StatusMethodNotAllowed, object{"code": -1000, "msg": "Method not allowed."})
+		return
+	}
+
+	now := s.Now()
+	if minute := now.Truncate(time.Minute); minute.After(s.weightMinute) {
+		s.weightMinute, s.weight = minute, 0
+	}
+	q := r.URL.Query()
+	weight := ep.weight
+	if s.weight+weight > s.WeightLimit {
+		retry := s.weightMinute.Add(time.Minute).Sub(now)
+		s.writeFault(w, fault{http.StatusTooManyRequests, retry})
+		return
+	}
+	s.weight += weight
+	res, err := ep.fn(s, q)
+	if err != nil {
+		e, ok := err.(*apiError)
+		if !ok {
+			e = &apiError{status: http.StatusInternalServerError, code: -1000, msg: err.Error()}
+		}
+		s.writeJSON(w, e.status, object{"code": e.code, "msg": e.msg})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) writeFault(w http.ResponseWriter, f fault) {
+	switch f.status {
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(f.retryAfter.Seconds()))))
+		msg := "Too many requests; current limit of IP is exceeded."
+		if f.status == http.StatusTeapot {
+			msg = fmt.Sprintf("Way too many requests; IP banned until %d.", s.Now().Add(f.retryAfter).UnixMilli())
+		}
+		s.writeJSON(w, f.status, object{"code": -1003, "msg": msg})
+	default:
+		s.writeJSON(w, f.status, object{"code": -1001, "msg": "Internal error; unable to process your request. Please try again."})
+	}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-MBX-USED-WEIGHT-1M", strconv.Itoa(s.weight))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func millis(q url.Values, key string) (time.Time, bool, error) {
+	v := q.Get(key)
+	if v == "" {
+		return time.Time{}, false, nil
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false, badRequest(-1100, "Illegal characters found in parameter '%s'; legal range is '^[0-9]{1,20}$'.", key)
+	}
+	return time.UnixMilli(ms), true, nil
+}
+
+// serveKlines answers like Binance: with startTime, the first limit candles
+// from it; otherwise the last limit candles up to endTime or now.
+func (s *Server) serveKlines(q url.Values) (interface{}, error) {
+	symbol := q.Get("symbol")
+	if _, ok := s.symbols[symbol]; !ok {
+		return nil, errInvalidSymbol
+	}
+	interval := q.Get("interval")
+	if _, err := client.IntervalDuration(interval); err != nil {
+		return nil, badRequest(-1120, "Invalid interval.")
+	}
+	limit := 500
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, badRequest(-1100, "Illegal characters found in parameter 'limit'; legal range is '^[0-9]{1,20}$'.")
+		}
+		limit = n
+	}
+	if limit > client.MaxKlineLimit {
+		limit = client.MaxKlineLimit
+	}
+	start, hasStart, err := millis(q, "startTime")
+	if err != nil {
+		return nil, err
+	}
+	end, hasEnd, err := millis(q, "endTime")
+	if err != nil {
+		return nil, err
+	}
+	if !hasStart && !hasEnd {
+		end, hasEnd = s.Now(), true
+	}
+
+	var sel []client.Kline
+	for _, k := range s.klines[symbol][interval] {
+		if hasStart && k.OpenTime.Before(start) || hasEnd && k.OpenTime.After(end) {
+			continue
+		}
+		sel = append(sel, k)
+		if hasStart && len(sel) == limit {
+			break
+		}
+	}
+	if len(sel) > limit {
+		sel = sel[len(sel)-limit:]
+	}
+	rows := make([][]interface{}, 0, len(sel))
+	for _, k := range sel {
+		rows = append(rows, []interface{}{
+			k.OpenTime.UnixMilli(), k.Open, k.High, k.Low, k.Close, k.Volume,
+			k.CloseTime.UnixMilli(), k.QuoteVolume, k.Trades,
+			k.TakerBuyBaseVolume, k.TakerBuyQuoteVolume, "0",
+		})
+	}
+	return rows, nil
+}
+
+// finest returns the klines of symbol with the shortest interval.
+func (s *Server) finest(symbol string) []client.Kline {
+	var best []client.Kline
+	var bestDur time.Duration
+	for interval, klines := range s.klines[symbol] {
+		dur, _ := client.IntervalDuration(interval)
+		if best == nil || dur < bestDur {
+			best, bestDur = klines, dur
+		}
+	}
+	return best
+}
+
+// serveTicker derives the 24h statistics from the finest kline series of
+// the symbol that has opened by now.
+func (s *Server) serveTicker(q url.Values) (interface{}, error) {
+	symbol := q.Get("symbol")
+	info, ok := s.symbols[symbol]
+	if !ok {
+		return nil, errInvalidSymbol
+	}
+	series := s.finest(symbol)
+	now := s.Now()
+	var day []client.Kline
+	for _, k := range series {
+		if !k.OpenTime.After(now) && now.Sub(k.OpenTime) < 24*time.Hour {
+			day = append(day, k)
+		}
+	}
+	if len(day) == 0 {
+		return nil, &apiError{status: http.StatusBadRequest, code: -1000, msg: "No klines in the last 24h."}
+	}
+
+	first, last := day[0], day[len(day)-1]
+	high, low := first.High, first.Low
+	var volume, quoteVolume decimal.Decimal
+	var count int64
+	for _, k := range day {
+		high = decimal.Max(high, k.High)
+		low = decimal.Min(low, k.Low)
+		volume = volume.Add(k.Volume)
+		quoteVolume = quoteVolume.Add(k.QuoteVolume)
+		count += k.Trades
+	}
+	change := last.Close.Sub(first.Open)
+	avg := decimal.Zero
+	if volume.IsPositive() {
+		avg = quoteVolume.Div(volume).Round(places)
+	}
+	tick := info.TickSize()
+	one := decimal.NewFromInt(1)
+	closeTime := last.CloseTime
+	if closeTime.After(now) {
+		closeTime = now
+	}
+	return object{
+		"symbol":             symbol,
+		"priceChange":        change,
+		"priceChangePercent": change.Div(first.Open).Mul(decimal.NewFromInt(100)).Round(3),
+		"weightedAvgPrice":   avg,
+		"prevClosePrice":     first.Open,
+		"lastPrice":          last.Close,
+		"lastQty":            one,
+		"bidPrice":           last.Close.Sub(tick),
+		"bidQty":             one,
+		"askPrice":           last.Close.Add(tick),
+		"askQty":             one,
+		"openPrice":          first.Open,
+		"highPrice":          high,
+		"lowPrice":           low,
+		"volume":             volume,
+		"quoteVolume":        quoteVolume,
+		"openTime":           first.OpenTime.UnixMilli(),
+		"closeTime":          closeTime.UnixMilli(),
+		"firstId":            1,
+		"lastId":             count,
+		"count":              count,
+	}, nil
+}
+
+// serveExchangeInfo lists every symbol, or those named by symbol or by the
+// JSON array in symbols.
+func (s *Server) serveExchangeInfo(q url.Values) (interface{}, error) {
+	names := s.listed
+	if v := q.Get("symbol"); v != "" {
+		names = []string{v}
+	} else if v := q.Get("symbols"); v != "" {
+		names = nil
+		if err := json.Unmarshal([]byte(v), &names); err != nil {
+			return nil, badRequest(-1100, "Illegal characters found in parameter 'symbols'.")
+		}
+	}
+	symbols := make([]client.SymbolInfo, 0, len(names))
+	for _, name := range names {
+		info, ok := s.symbols[name]
+		if !ok {
+			return nil, errInvalidSymbol
+		}
+		symbols = append(symbols, info)
+	}
+	return object{
+		"timezone":   "UTC",
+		"serverTime": s.Now().UnixMilli(),
+		"rateLimits": []client.RateLimit{
+			{RateLimitType: "REQUEST_WEIGHT", Interval: "MINUTE", IntervalNum: 1, Limit: s.WeightLimit},
+		},
+		"symbols": symbols,
+	}, nil
+}
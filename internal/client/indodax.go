@@ -10,13 +10,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/shopspring/decimal"
 )
 
 const (
@@ -28,11 +25,33 @@ type Config struct {
 	APISecret string
 }
 
+// AuthMode selects how signed requests are protected against replay.
+type AuthMode int
+
+const (
+	// AuthNonce sends an increasing nonce with every request. It is the
+	// default, and breaks when several processes share a key.
+	AuthNonce AuthMode = iota
+	// AuthTimestamp sends the request time in server time and a recvWindow
+	// instead of a nonce.
+	AuthTimestamp
+)
+
 type IndodaxClient struct {
 	config     Config
 	httpClient *http.Client
+	endpoint   string
 	nonce      int64
 	mu         sync.Mutex
+
+	authMode   AuthMode
+	recvWindow time.Duration
+	// offset is added to the local clock to get server time
+	offset time.Duration
+
+	limiter    *Limiter
+	limitMode  RateLimitMode
+	maxRetries int
 }
 
 func NewClient(apiKey, apiSecret string, client *http.Client) *IndodaxClient {
@@ -42,7 +61,32 @@ func NewClient(apiKey, apiSecret string, client *http.Client) *IndodaxClient {
 	return &IndodaxClient{
 		config:     Config{APIKey: apiKey, APISecret: apiSecret},
 		httpClient: client,
+		endpoint:   apiURL,
 		nonce:      time.Now().UnixNano() / int64(time.Millisecond),
+		limiter:    NewLimiter(),
+		maxRetries: 1,
+	}
+}
+
+// SetBaseURL points the client at another /tapi endpoint, such as a test
+// server.
+func (c *IndodaxClient) SetBaseURL(u string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoint = u
+}
+
+// SetRateLimit chooses whether calls over the trade and cancel limits wait
+// or fail fast, and how often a 429 is retried in wait mode once its block
+// has passed. A nil limiter keeps the current one; pass a shared Limiter
+// when several clients use the same account.
+func (c *IndodaxClient) SetRateLimit(mode RateLimitMode, retries int, limiter *Limiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limitMode = mode
+	c.maxRetries = retries
+	if limiter != nil {
+		c.limiter = limiter
 	}
 }
 
@@ -53,13 +97,107 @@ func (c *IndodaxClient) nextNonce() int64 {
 	return c.nonce
 }
 
+// SetAuthMode switches between nonce and timestamp authentication. With
+// AuthTimestamp a request is valid for recvWindow after it was created, or
+// for the server's 5s default when recvWindow is zero; call Calibrate first
+// if the local clock may be off.
+func (c *IndodaxClient) SetAuthMode(mode AuthMode, recvWindow time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authMode = mode
+	c.recvWindow = recvWindow
+}
+
+// ClockOffset returns the estimated difference between server time and the
+// local clock.
+func (c *IndodaxClient) ClockOffset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Calibrate measures the server clock through getInfo and returns the new
+// offset. The call is signed with a nonce whatever the auth mode, since a
+// timestamp from a clock that is off is rejected before the server time can
+// be read. GetInfo recalibrates as a side effect as well.
+func (c *IndodaxClient) Calibrate(ctx context.Context) (time.Duration, error) {
+	if _, err := c.getInfo(ctx, AuthNonce); err != nil {
+		return 0, err
+	}
+	return c.ClockOffset(), nil
+}
+
+// observeServerTime updates the clock offset from a server_time (seconds)
+// received for a request sent at sent and answered at recv. The server
+// truncates to whole seconds, so the offset errs towards the past: Indodax
+// rejects timestamps more than 1s ahead of it, while recvWindow absorbs a
+// timestamp that is slightly behind.
+func (c *IndodaxClient) observeServerTime(serverTime int64, sent, recv time.Time) {
+	if serverTime <= 0 {
+		return
+	}
+	mid := sent.Add(recv.Sub(sent) / 2)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = time.Unix(serverTime, 0).Sub(mid)
+}
+
+// authParams adds the nonce or timestamp fields of mode.
+func (c *IndodaxClient) authParams(params map[string]string, mode AuthMode) {
+	c.mu.Lock()
+	window, offset := c.recvWindow, c.offset
+	c.mu.Unlock()
+	if mode == AuthNonce {
+		params["nonce"] = strconv.FormatInt(c.nextNonce(), 10)
+		return
+	}
+	params["timestamp"] = strconv.FormatInt(time.Now().Add(offset).UnixMilli(), 10)
+	if window > 0 {
+		params["recvWindow"] = strconv.FormatInt(window.Milliseconds(), 10)
+	}
+}
+
 func (c *IndodaxClient) doSigned(ctx context.Context, method string, params map[string]string, out interface{}) error {
+	c.mu.Lock()
+	mode := c.authMode
+	c.mu.Unlock()
+	return c.doSignedAs(ctx, mode, method, params, out)
+}
+
+// doSignedAs calls method signed with the given auth mode.
+func (c *IndodaxClient) doSignedAs(ctx context.Context, auth AuthMode, method string, params map[string]string, out interface{}) error {
 	if params == nil {
 		params = make(map[string]string)
 	}
 	params["method"] = method
-	params["nonce"] = strconv.FormatInt(c.nextNonce(), 10)
+	pair := params["pair"]
+
+	c.mu.Lock()
+	limiter, mode, retries := c.limiter, c.limitMode, c.maxRetries
+	c.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.acquire(ctx, mode, method, pair); err != nil {
+			return err
+		}
+		// every attempt is signed again with a fresh nonce or timestamp
+		c.authParams(params, auth)
+		status, data, err := c.send(ctx, params)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusTooManyRequests {
+			limiter.Block(method, pair)
+			if mode == RateLimitWait && attempt < retries {
+				continue
+			}
+		}
+		return decodeSigned(method, status, data, out)
+	}
+}
 
+// send signs params and posts them to the private API.
+func (c *IndodaxClient) send(ctx context.Context, params map[string]string) (int, []byte, error) {
 	form := url.Values{}
 	for k, v := range params {
 		form.Set(k, v)
@@ -70,9 +208,12 @@ func (c *IndodaxClient) doSigned(ctx context.Context, method string, params map[
 	mac.Write([]byte(body))
 	sig := hex.EncodeToString(mac.Sum(nil))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, strings.NewReader(body))
+	c.mu.Lock()
+	endpoint := c.endpoint
+	c.mu.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return 0, nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Key", c.config.APIKey)
@@ -80,46 +221,57 @@ func (c *IndodaxClient) doSigned(ctx context.Context, method string, params map[
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("do request: %w", err)
+		return 0, nil, fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		time.Sleep(500 * time.Millisecond)
-		resp, err = c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("retry request: %w", err)
-		}
-		defer resp.Body.Close()
-	}
-
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return 0, nil, fmt.Errorf("read response: %w", err)
+	}
+	return resp.StatusCode, data, nil
+}
+
+func decodeSigned(method string, status int, data []byte, out interface{}) error {
+	var base BaseResponse
+	decodeErr := json.Unmarshal(data, &base)
+	if status != http.StatusOK {
+		apiErr := &APIError{Code: base.ErrorCode, Message: base.Error, HTTPStatus: status, Method: method}
+		if decodeErr != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
 	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http %d: %s", resp.StatusCode, data)
+	if decodeErr != nil {
+		return fmt.Errorf("unmarshal: %w", decodeErr)
+	}
+	// withdrawCoin can report success 1 alongside an error
+	if !base.Success || base.Error != "" {
+		return &APIError{Code: base.ErrorCode, Message: base.Error, HTTPStatus: status, Method: method}
 	}
 
 	if err := json.Unmarshal(data, out); err != nil {
 		return fmt.Errorf("unmarshal: %w", err)
 	}
-
-	val := reflect.ValueOf(out).Elem()
-	f := val.FieldByName("Success")
-	if f.IsValid() && f.Int() == 0 {
-		errFld := val.FieldByName("Error")
-		return fmt.Errorf("api error: %s", errFld.String())
-	}
-
 	return nil
 }
 
 func (c *IndodaxClient) GetInfo(ctx context.Context) (*GetInfoResponse, error) {
+	c.mu.Lock()
+	mode := c.authMode
+	c.mu.Unlock()
+	return c.getInfo(ctx, mode)
+}
+
+func (c *IndodaxClient) getInfo(ctx context.Context, auth AuthMode) (*GetInfoResponse, error) {
 	var res GetInfoResponse
-	if err := c.doSigned(ctx, "getInfo", nil, &res); err != nil {
+	sent := time.Now()
+	if err := c.doSignedAs(ctx, auth, "getInfo", nil, &res); err != nil {
 		return nil, err
 	}
+	if !res.Return.ServerTime.IsZero() {
+		c.observeServerTime(res.Return.ServerTime.Unix(), sent, time.Now())
+	}
 	return &res, nil
 }
 
@@ -138,27 +290,18 @@ func (c *IndodaxClient) GetTransactionHistory(ctx context.Context, start, end st
 	return &res, nil
 }
 
-func (c *IndodaxClient) Trade(ctx context.Context, pair, tradeType string, price, amount, idrAmount decimal.Decimal, clientID, tif string) (*TradeResponse, error) {
-	params := map[string]string{"pair": pair, "type": tradeType}
-	if !price.IsZero() {
-		params["price"] = price.String()
-	}
-	if !amount.IsZero() {
-		params["btc"] = amount.String()
-	}
-	if !idrAmount.IsZero() {
-		params["idr"] = idrAmount.String()
-	}
-	if clientID != "" {
-		params["client_order_id"] = clientID
-	}
-	if tif != "" {
-		params["time_in_force"] = tif
+// Trade places req after validating it; a request that breaks the trade
+// API's rules fails with ErrInvalidOrder without being sent.
+func (c *IndodaxClient) Trade(ctx context.Context, req OrderRequest) (*TradeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
+	params := req.params()
 	var res TradeResponse
 	if err := c.doSigned(ctx, "trade", params, &res); err != nil {
 		return nil, err
 	}
+	res.Return.setPair(req.Pair)
 	return &res, nil
 }
 
@@ -171,6 +314,11 @@ func (c *IndodaxClient) GetTradeHistory(ctx context.Context, pair string, opts .
 	if err := c.doSigned(ctx, "tradeHistory", params, &res); err != nil {
 		return nil, err
 	}
+	for i := range res.Return.Trades {
+		if err := res.Return.Trades[i].setPair(pair); err != nil {
+			return nil, err
+		}
+	}
 	return &res, nil
 }
 
@@ -183,6 +331,7 @@ func (c *IndodaxClient) GetOpenOrders(ctx context.Context, pair string) (*OpenOr
 	if err := c.doSigned(ctx, "openOrders", params, &res); err != nil {
 		return nil, err
 	}
+	res.Return.Orders.setPair(pair)
 	return &res, nil
 }
 
@@ -198,6 +347,7 @@ func (c *IndodaxClient) GetOrderHistory(ctx context.Context, pair string, count,
 	if err := c.doSigned(ctx, "orderHistory", params, &res); err != nil {
 		return nil, err
 	}
+	res.Return.Orders.setPair(pair)
 	return &res, nil
 }
 
@@ -207,6 +357,7 @@ func (c *IndodaxClient) GetOrder(ctx context.Context, pair string, orderID int64
 	if err := c.doSigned(ctx, "getOrder", params, &res); err != nil {
 		return nil, err
 	}
+	res.Return.Order.setPair(pair)
 	return &res, nil
 }
 
@@ -0,0 +1,419 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const indodaxPublicURL = "https://indodax.com"
+
+// IndodaxPublicClient calls the unauthenticated Indodax market-data API.
+// Pairs may be written as in ticker_all ("btc_idr") or as pair ids
+// ("btcidr").
+type IndodaxPublicClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPublicClient returns a client for baseURL, or indodax.com when it is
+// empty.
+func NewPublicClient(baseURL string, client *http.Client) *IndodaxPublicClient {
+	if baseURL == "" {
+		baseURL = indodaxPublicURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &IndodaxPublicClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: client,
+	}
+}
+
+// UnixSeconds is a time decoded from a unix timestamp in seconds, sent by
+// Indodax either as a number or as a string.
+type UnixSeconds struct {
+	time.Time
+}
+
+func (t *UnixSeconds) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" || s == "0" {
+		t.Time = time.Time{}
+		return nil
+	}
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("timestamp: %w", err)
+	}
+	t.Time = time.Unix(sec, 0).UTC()
+	return nil
+}
+
+func (t UnixSeconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Time)
+}
+
+// pairID turns "btc_idr" into the "btcidr" form used in URLs.
+func pairID(pair string) string {
+	return strings.ToLower(strings.ReplaceAll(pair, "_", ""))
+}
+
+// Ticker represents the 24h summary of a pair. Indodax names the volume
+// fields after the coins (vol_btc, vol_idr); they are mapped to the base
+// and quote volume here.
+type Ticker struct {
+	Pair        string          `json:"pair"`
+	Name        string          `json:"name,omitempty"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Last        decimal.Decimal `json:"last"`
+	Buy         decimal.Decimal `json:"buy"`
+	Sell        decimal.Decimal `json:"sell"`
+	VolumeBase  decimal.Decimal `json:"volume_base"`
+	VolumeQuote decimal.Decimal `json:"volume_quote"`
+	ServerTime  UnixSeconds     `json:"server_time"`
+}
+
+func parseTicker(pair string, raw map[string]json.RawMessage) (Ticker, error) {
+	t := Ticker{Pair: pair}
+	fields := []struct {
+		key string
+		dst *decimal.Decimal
+	}{
+		{"high", &t.High},
+		{"low", &t.Low},
+		{"last", &t.Last},
+		{"buy", &t.Buy},
+		{"sell", &t.Sell},
+	}
+	for _, f := range fields {
+		v, ok := raw[f.key]
+		if !ok {
+			return Ticker{}, fmt.Errorf("ticker %s: missing %s", pair, f.key)
+		}
+		if err := json.Unmarshal(v, f.dst); err != nil {
+			return Ticker{}, fmt.Errorf("ticker %s %s: %w", pair, f.key, err)
+		}
+	}
+	// vol_btc and vol_idr of btcidr: the coin the pair starts with is the
+	// base, the one it ends with the quote
+	id := pairID(pair)
+	for key, v := range raw {
+		coin, ok := strings.CutPrefix(key, "vol_")
+		if !ok {
+			continue
+		}
+		dst := &t.VolumeQuote
+		if strings.HasPrefix(id, coin) {
+			dst = &t.VolumeBase
+		} else if !strings.HasSuffix(id, coin) {
+			continue
+		}
+		if err := json.Unmarshal(v, dst); err != nil {
+			return Ticker{}, fmt.Errorf("ticker %s %s: %w", pair, key, err)
+		}
+	}
+	if v, ok := raw["server_time"]; ok {
+		if err := json.Unmarshal(v, &t.ServerTime); err != nil {
+			return Ticker{}, fmt.Errorf("ticker %s server_time: %w", pair, err)
+		}
+	}
+	if v, ok := raw["name"]; ok {
+		json.Unmarshal(v, &t.Name)
+	}
+	return t, nil
+}
+
+// PublicTrade represents one trade from the trades endpoint
+type PublicTrade struct {
+	Date   UnixSeconds     `json:"date"`
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"`
+	TID    string          `json:"tid"`
+	Type   string          `json:"type"`
+}
+
+// DepthLevel represents one [price, amount] entry of an order book
+type DepthLevel struct {
+	Price  decimal.Decimal
+	Amount decimal.Decimal
+}
+
+func (l *DepthLevel) UnmarshalJSON(b []byte) error {
+	var pair []decimal.Decimal
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return fmt.Errorf("depth level: %w", err)
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("depth level has %d fields, want 2", len(pair))
+	}
+	l.Price, l.Amount = pair[0], pair[1]
+	return nil
+}
+
+// OrderBook represents the depth endpoint; Buy is best bid first and Sell
+// best ask first.
+type OrderBook struct {
+	Buy  []DepthLevel `json:"buy"`
+	Sell []DepthLevel `json:"sell"`
+}
+
+// Pair represents a market from the pairs endpoint
+type Pair struct {
+	ID                     string          `json:"id"`
+	Symbol                 string          `json:"symbol"`
+	TickerID               string          `json:"ticker_id"`
+	BaseCurrency           string          `json:"base_currency"`
+	TradedCurrency         string          `json:"traded_currency"`
+	TradedCurrencyUnit     string          `json:"traded_currency_unit"`
+	Description            string          `json:"description"`
+	VolumePrecision        int             `json:"volume_precision"`
+	PricePrecision         decimal.Decimal `json:"price_precision"`
+	PriceRound             int             `json:"price_round"`
+	PriceScale             decimal.Decimal `json:"pricescale"`
+	TradeMinBaseCurrency   decimal.Decimal `json:"trade_min_base_currency"`
+	TradeMinTradedCurrency decimal.Decimal `json:"trade_min_traded_currency"`
+	TradeFeePercent        decimal.Decimal `json:"trade_fee_percent"`
+	IsMaintenance          int             `json:"is_maintenance"`
+	IsMarketSuspended      int             `json:"is_market_suspended"`
+}
+
+// Open reports whether the market is neither in maintenance nor suspended.
+func (p Pair) Open() bool {
+	return p.IsMaintenance == 0 && p.IsMarketSuspended == 0
+}
+
+// get fetches path and decodes the JSON reply into out.
+func (c *IndodaxPublicClient) get(ctx context.Context, path string, q url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if q != nil {
+		req.URL.RawQuery = q.Encode()
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http %d: %s", resp.StatusCode, data)
+	}
+	// unknown pairs come back as 200 with {"error": ..., "error_description": ...}
+	var apiErr struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+		return fmt.Errorf("api error: %s: %s", apiErr.Error, apiErr.Description)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	return nil
+}
+
+func (c *IndodaxPublicClient) ServerTime(ctx context.Context) (time.Time, error) {
+	var res struct {
+		ServerTime int64 `json:"server_time"`
+	}
+	if err := c.get(ctx, "/api/server_time", nil, &res); err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(res.ServerTime).UTC(), nil
+}
+
+func (c *IndodaxPublicClient) Ticker(ctx context.Context, pair string) (*Ticker, error) {
+	var res struct {
+		Ticker map[string]json.RawMessage `json:"ticker"`
+	}
+	if err := c.get(ctx, "/api/ticker/"+pairID(pair), nil, &res); err != nil {
+		return nil, err
+	}
+	t, err := parseTicker(pair, res.Ticker)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// TickerAll returns the ticker of every pair, keyed by pair.
+func (c *IndodaxPublicClient) TickerAll(ctx context.Context) (map[string]Ticker, error) {
+	var res struct {
+		Tickers map[string]map[string]json.RawMessage `json:"tickers"`
+	}
+	if err := c.get(ctx, "/api/ticker_all", nil, &res); err != nil {
+		return nil, err
+	}
+	out := make(map[string]Ticker, len(res.Tickers))
+	for pair, raw := range res.Tickers {
+		t, err := parseTicker(pair, raw)
+		if err != nil {
+			return nil, err
+		}
+		out[pair] = t
+	}
+	return out, nil
+}
+
+// Trades returns the most recent trades of a pair, newest first.
+func (c *IndodaxPublicClient) Trades(ctx context.Context, pair string) ([]PublicTrade, error) {
+	var res []PublicTrade
+	if err := c.get(ctx, "/api/trades/"+pairID(pair), nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *IndodaxPublicClient) Depth(ctx context.Context, pair string) (*OrderBook, error) {
+	var res OrderBook
+	if err := c.get(ctx, "/api/depth/"+pairID(pair), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *IndodaxPublicClient) Pairs(ctx context.Context) ([]Pair, error) {
+	var res []Pair
+	if err := c.get(ctx, "/api/pairs", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PriceIncrements returns the price tick of every pair, keyed by pair.
+func (c *IndodaxPublicClient) PriceIncrements(ctx context.Context) (map[string]decimal.Decimal, error) {
+	var res struct {
+		Increments map[string]decimal.Decimal `json:"increments"`
+	}
+	if err := c.get(ctx, "/api/price_increments", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Increments, nil
+}
+
+// indodaxTimeframes maps kline intervals to tradingview history timeframes.
+// The feed has no 3m, 5m, 2h, 6h, 8h or 12h resolution.
+var indodaxTimeframes = map[string]string{
+	"1m":  "1",
+	"15m": "15",
+	"30m": "30",
+	"1h":  "60",
+	"4h":  "240",
+	"1d":  "1D",
+	"3d":  "3D",
+	"1w":  "1W",
+}
+
+// IndodaxInterval reports whether Indodax serves candles of interval.
+func IndodaxInterval(interval string) bool {
+	_, ok := indodaxTimeframes[interval]
+	return ok
+}
+
+type ohlcEntry struct {
+	Time   int64           `json:"Time"`
+	Open   decimal.Decimal `json:"Open"`
+	High   decimal.Decimal `json:"High"`
+	Low    decimal.Decimal `json:"Low"`
+	Close  decimal.Decimal `json:"Close"`
+	Volume decimal.Decimal `json:"Volume"`
+}
+
+// OHLC returns the candles of a pair opening between from and to, oldest
+// first. Only Volume (in the traded coin) is available; the other volume
+// fields of Kline are left zero.
+func (c *IndodaxPublicClient) OHLC(ctx context.Context, pair, interval string, from, to time.Time) ([]Kline, error) {
+	tf, ok := indodaxTimeframes[interval]
+	if !ok {
+		return nil, fmt.Errorf("indodax has no %q candles", interval)
+	}
+	dur, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Set("symbol", strings.ToUpper(pairID(pair)))
+	q.Set("tf", tf)
+	q.Set("from", strconv.FormatInt(from.Unix(), 10))
+	q.Set("to", strconv.FormatInt(to.Unix(), 10))
+	var raw []ohlcEntry
+	if err := c.get(ctx, "/tradingview/history_v2", q, &raw); err != nil {
+		return nil, fmt.Errorf("ohlc %s %s: %w", pair, interval, err)
+	}
+	klines := make([]Kline, len(raw))
+	for i, e := range raw {
+		open := time.Unix(e.Time, 0).UTC()
+		klines[i] = Kline{
+			OpenTime:  open,
+			CloseTime: open.Add(dur - time.Millisecond),
+			Open:      e.Open,
+			High:      e.High,
+			Low:       e.Low,
+			Close:     e.Close,
+			Volume:    e.Volume,
+		}
+	}
+	return klines, nil
+}
+
+// maxWiden bounds how often KlinesBefore doubles its window.
+const maxWiden = 4
+
+// KlinesBefore returns the last n candles of a pair opening no later than
+// end, oldest first, like BinanceClient.KlinesBefore. The history endpoint
+// only takes a time range, so the range is doubled while gaps leave it
+// short, until a wider range finds nothing older.
+func (c *IndodaxPublicClient) KlinesBefore(ctx context.Context, pair, interval string, end time.Time, n int) ([]Kline, error) {
+	dur, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	span := time.Duration(n-1) * dur
+	var klines []Kline
+	for widen := 0; ; widen++ {
+		got, _, err := c.KlinesBetween(ctx, pair, interval, end.Add(-span), end)
+		if err != nil {
+			return nil, err
+		}
+		if len(got) >= n {
+			return got[len(got)-n:], nil
+		}
+		if widen == maxWiden || widen > 0 && len(got) == len(klines) {
+			return got, nil
+		}
+		klines = got
+		span *= 2
+	}
+}
+
+// KlinesBetween returns the candles of a pair opening between start and end
+// with the gaps between them, like BinanceClient.KlinesBetween.
+func (c *IndodaxPublicClient) KlinesBetween(ctx context.Context, pair, interval string, start, end time.Time) ([]Kline, []Gap, error) {
+	dur, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, err := c.OHLC(ctx, pair, interval, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	klines, gaps, _ := appendKlines(nil, nil, page, dur)
+	return klines, gaps, nil
+}
@@ -0,0 +1,214 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/client/indodaxtest"
+)
+
+func newIndodax(t *testing.T) (*indodaxtest.Server, *client.IndodaxClient) {
+	t.Helper()
+	srv := indodaxtest.NewServer("key", "secret")
+	t.Cleanup(srv.Close)
+	return srv, srv.IndodaxClient()
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestIndodaxGetInfo(t *testing.T) {
+	srv, c := newIndodax(t)
+	srv.SetBalance("idr", dec("1500000"))
+	srv.SetBalance("btc", dec("0.25"))
+
+	res, err := c.GetInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Return.Balance["idr"]; !got.Equal(dec("1500000")) {
+		t.Errorf("idr balance = %s, want 1500000", got)
+	}
+	if got := res.Return.Balance["btc"]; !got.Equal(dec("0.25")) {
+		t.Errorf("btc balance = %s, want 0.25", got)
+	}
+	if res.Return.UserID != srv.UserID {
+		t.Errorf("user id = %q, want %q", res.Return.UserID, srv.UserID)
+	}
+}
+
+func TestIndodaxTradeAndCancel(t *testing.T) {
+	srv, c := newIndodax(t)
+	srv.SetBalance("idr", dec("10000000"))
+	ctx := context.Background()
+
+	req, err := client.NewOrder("btc_idr", client.Buy).
+		Limit(dec("500000000")).Amount(dec("0.01")).ClientOrderID("test-1").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, err := c.Trade(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed.Return.OrderID == 0 || placed.Return.ClientOrderID != "test-1" {
+		t.Fatalf("trade returned %+v", placed.Return)
+	}
+	if _, hold := srv.Balance("idr"); !hold.Equal(dec("5000000")) {
+		t.Errorf("idr held = %s, want 5000000", hold)
+	}
+
+	cancelled, err := c.CancelOrder(ctx, "btc_idr", "buy", placed.Return.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Return.OrderID != placed.Return.OrderID {
+		t.Errorf("cancelled order %d, want %d", cancelled.Return.OrderID, placed.Return.OrderID)
+	}
+	orders := srv.Orders()
+	if len(orders) != 1 || orders[0].Status != indodaxtest.StatusCancelled {
+		t.Fatalf("orders = %+v, want one cancelled", orders)
+	}
+	if avail, hold := srv.Balance("idr"); !avail.Equal(dec("10000000")) || !hold.IsZero() {
+		t.Errorf("idr after cancel = %s available, %s held", avail, hold)
+	}
+
+	_, err = c.Trade(ctx, req)
+	if !errors.Is(err, client.ErrDuplicateOrderID) {
+		t.Errorf("reused client order id: err = %v, want ErrDuplicateOrderID", err)
+	}
+}
+
+func TestIndodaxStaleNonce(t *testing.T) {
+	srv, c := newIndodax(t)
+	other := srv.IndodaxClient()
+	ctx := context.Background()
+
+	// other is created later and calls twice, so its nonce passes c's
+	for i := 0; i < 2; i++ {
+		if _, err := other.GetInfo(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := c.GetInfo(ctx)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.Method != "getInfo" || apiErr.HTTPStatus != http.StatusOK {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if apiErr.Retryable() {
+		t.Error("stale nonce reported as retryable")
+	}
+}
+
+func TestIndodaxRetriesTooManyRequests(t *testing.T) {
+	srv, c := newIndodax(t)
+	srv.SetBalance("idr", dec("10000000"))
+	limiter := client.NewLimiter()
+	limiter.SetBlock(200 * time.Millisecond)
+	c.SetRateLimit(client.RateLimitWait, 1, limiter)
+	srv.Fail("trade", http.StatusTooManyRequests, "too_many_requests",
+		"Your User ID sent too many trade request for pair BTCIDR, please try again in 5 seconds")
+
+	req, err := client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	// the failed attempt used up its nonce, so the retry only passes if it
+	// was signed again
+	if _, err := c.Trade(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("retried after %s, before the block ended", waited)
+	}
+	if n := len(srv.Orders()); n != 1 {
+		t.Errorf("%d orders placed, want 1", n)
+	}
+
+	c.SetRateLimit(client.RateLimitFailFast, 0, nil)
+	srv.Fail("trade", http.StatusTooManyRequests, "too_many_requests", "Your User ID sent too many trade request")
+	_, err = c.Trade(context.Background(), req)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !apiErr.Retryable() || !errors.Is(err, client.ErrTooManyRequests) {
+		t.Fatalf("err = %v, want a retryable ErrTooManyRequests", err)
+	}
+	_, err = c.Trade(context.Background(), req)
+	if !errors.Is(err, client.ErrTooManyRequests) || errors.As(err, new(*client.APIError)) {
+		t.Errorf("call during block: err = %v, want ErrTooManyRequests before sending", err)
+	}
+}
+
+func TestIndodaxPermissionDenied(t *testing.T) {
+	srv, c := newIndodax(t)
+	srv.Permissions = []string{indodaxtest.PermView}
+
+	req, err := client.NewOrder("btc_idr", client.Sell).Limit(dec("500000000")).Amount(dec("0.01")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Trade(context.Background(), req)
+	if !errors.Is(err, client.ErrPermissionDenied) {
+		t.Fatalf("err = %v, want ErrPermissionDenied", err)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Method != "trade" {
+		t.Errorf("err = %#v, want *APIError for trade", err)
+	}
+	if _, err := c.GetInfo(context.Background()); err != nil {
+		t.Errorf("getInfo with view permission: %v", err)
+	}
+}
+
+func TestIndodaxCalibrateWithSkewedClock(t *testing.T) {
+	srv, c := newIndodax(t)
+	// the local clock runs a minute ahead of the server
+	srv.Now = func() time.Time { return time.Now().Add(-time.Minute) }
+	c.SetAuthMode(client.AuthTimestamp, 5*time.Second)
+	ctx := context.Background()
+
+	var apiErr *client.APIError
+	if _, err := c.GetInfo(ctx); !errors.As(err, &apiErr) {
+		t.Fatalf("uncalibrated getInfo: err = %v, want *APIError", err)
+	}
+	offset, err := c.Calibrate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset > -59*time.Second || offset < -62*time.Second {
+		t.Errorf("offset = %s, want about -1m", offset)
+	}
+	if _, err := c.GetInfo(ctx); err != nil {
+		t.Errorf("getInfo after Calibrate: %v", err)
+	}
+}
+
+func TestIndodaxObservedErrors(t *testing.T) {
+	srv, c := newIndodax(t)
+	srv.SetBalance("idr", dec("1000"))
+	ctx := context.Background()
+
+	req, err := client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Trade(ctx, req); !errors.Is(err, client.ErrInsufficientBalance) {
+		t.Errorf("trade without funds: err = %v, want ErrInsufficientBalance", err)
+	}
+	if _, err := c.CancelOrder(ctx, "btc_idr", "buy", 12345); !errors.Is(err, client.ErrOrderNotFound) {
+		t.Errorf("cancel of unknown order: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := c.GetOrder(ctx, "btc_idr", 12345); !errors.Is(err, client.ErrOrderNotFound) {
+		t.Errorf("get of unknown order: err = %v, want ErrOrderNotFound", err)
+	}
+}
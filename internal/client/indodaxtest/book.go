@@ -0,0 +1,179 @@
+package indodaxtest
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// coinPlaces is the precision of coin amounts on Indodax.
+const coinPlaces = 8
+
+func splitPair(pair string) (base, quote string, ok bool) {
+	i := strings.IndexByte(pair, '_')
+	if i <= 0 || i == len(pair)-1 {
+		return "", "", false
+	}
+	return pair[:i], pair[i+1:], true
+}
+
+func opposite(side string) string {
+	if side == "buy" {
+		return "sell"
+	}
+	return "buy"
+}
+
+func (s *Server) newOrder(pair, side, orderType string, price, amount decimal.Decimal) *Order {
+	s.nextID++
+	o := &Order{
+		ID:          s.nextID,
+		Pair:        pair,
+		Side:        side,
+		OrderType:   orderType,
+		TimeInForce: "GTC",
+		Price:       price,
+		Amount:      amount,
+		Remain:      amount,
+		Status:      StatusOpen,
+		SubmitTime:  s.Now(),
+	}
+	s.orders = append(s.orders, o)
+	return o
+}
+
+// resting returns the open orders on one side of pair, best price first
+// and oldest first within a price.
+func (s *Server) resting(pair, side string) []*Order {
+	var out []*Order
+	for _, o := range s.orders {
+		if o.Pair == pair && o.Side == side && o.Status == StatusOpen && o.OrderType == "limit" {
+			out = append(out, o)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if side == "buy" {
+			return out[i].Price.GreaterThan(out[j].Price)
+		}
+		return out[i].Price.LessThan(out[j].Price)
+	})
+	return out
+}
+
+// crosses reports whether a limit order at price would trade against the
+// other traders in the book.
+func (s *Server) crosses(pair, side string, price decimal.Decimal) bool {
+	for _, m := range s.resting(pair, opposite(side)) {
+		if m.own {
+			continue
+		}
+		return side == "buy" && m.Price.LessThanOrEqual(price) || side == "sell" && m.Price.GreaterThanOrEqual(price)
+	}
+	return false
+}
+
+// match fills t against the other side of the book at the resting prices.
+// The account never trades with itself, and other traders only trade with
+// the account.
+func (s *Server) match(t *Order) {
+	for _, m := range s.resting(t.Pair, opposite(t.Side)) {
+		if m.own == t.own {
+			continue
+		}
+		if t.OrderType == "limit" && (t.Side == "buy" && m.Price.GreaterThan(t.Price) || t.Side == "sell" && m.Price.LessThan(t.Price)) {
+			break
+		}
+		qty := decimal.Min(t.Remain, m.Remain)
+		if t.OrderType == "market" && t.Side == "buy" {
+			qty = decimal.Min(m.Remain, t.budget.Div(m.Price).Truncate(coinPlaces))
+		}
+		if !qty.IsPositive() {
+			break
+		}
+		s.fill(t, qty, m.Price, false)
+		s.fill(m, qty, m.Price, true)
+		if t.OrderType == "market" && t.Side == "buy" {
+			t.budget = t.budget.Sub(qty.Mul(m.Price))
+		} else if t.Remain.IsZero() {
+			break
+		}
+	}
+}
+
+// fill books qty at price against o. Maker fills of the account's orders
+// are paid from the hold taken when the order started resting.
+func (s *Server) fill(o *Order, qty, price decimal.Decimal, maker bool) {
+	if o.OrderType == "limit" || o.Side == "sell" {
+		o.Remain = o.Remain.Sub(qty)
+	}
+	if o.Remain.IsZero() && o.OrderType == "limit" {
+		o.Status = StatusFilled
+		o.FinishTime = s.Now()
+	}
+	if !o.own {
+		return
+	}
+
+	base, quote, _ := splitPair(o.Pair)
+	pay, get := quote, base
+	paid, got := qty.Mul(price), qty
+	if o.Side == "sell" {
+		pay, get = base, quote
+		paid, got = qty, qty.Mul(price)
+	}
+	fee := got.Mul(s.FeeRate).Truncate(coinPlaces)
+	if maker {
+		// a resting buy holds its own price, which is the fill price
+		s.holds[pay] = s.holds[pay].Sub(paid)
+	} else {
+		s.balances[pay] = s.balances[pay].Sub(paid)
+	}
+	s.balances[get] = s.balances[get].Add(got.Sub(fee))
+	o.Spent = o.Spent.Add(paid)
+	o.Received = o.Received.Add(got.Sub(fee))
+	o.Fee = o.Fee.Add(fee)
+
+	s.nextID++
+	s.trades = append(s.trades, Trade{
+		ID:            s.nextID,
+		OrderID:       o.ID,
+		ClientOrderID: o.ClientOrderID,
+		Pair:          o.Pair,
+		Side:          o.Side,
+		Price:         price,
+		Amount:        qty,
+		Fee:           fee,
+		Time:          s.Now(),
+	})
+}
+
+// held returns the currency and amount an open order of the account holds.
+func held(o *Order) (string, decimal.Decimal) {
+	base, quote, _ := splitPair(o.Pair)
+	if o.Side == "buy" {
+		return quote, o.Remain.Mul(o.Price)
+	}
+	return base, o.Remain
+}
+
+func (s *Server) hold(o *Order) {
+	cur, amount := held(o)
+	s.balances[cur] = s.balances[cur].Sub(amount)
+	s.holds[cur] = s.holds[cur].Add(amount)
+}
+
+func (s *Server) release(o *Order) {
+	cur, amount := held(o)
+	s.holds[cur] = s.holds[cur].Sub(amount)
+	s.balances[cur] = s.balances[cur].Add(amount)
+}
+
+// amount formats a balance or order amount: coins with eight decimals, IDR
+// as is.
+func amount(currency string, d decimal.Decimal) string {
+	if currency == "idr" {
+		return d.String()
+	}
+	return d.StringFixed(coinPlaces)
+}
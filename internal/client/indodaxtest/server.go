@@ -0,0 +1,431 @@
+// Package indodaxtest provides an in-memory fake of the Indodax private API
+// (/tapi) so IndodaxClient and trading code can be exercised offline.
+package indodaxtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/shopspring/decimal"
+)
+
+// Permissions of an API key.
+const (
+	PermView     = "view"
+	PermTrade    = "trade"
+	PermWithdraw = "withdraw"
+)
+
+// Order statuses.
+const (
+	StatusOpen      = "open"
+	StatusFilled    = "filled"
+	StatusCancelled = "cancelled"
+)
+
+// Order represents an order on the fake exchange: one placed by the account
+// through trade, or one resting in the book for other traders.
+type Order struct {
+	ID            int64
+	ClientOrderID string
+	Pair          string
+	Side          string
+	OrderType     string
+	TimeInForce   string
+	Price         decimal.Decimal
+	// Amount and Remain are in the base coin. QuoteAmount is set for buy
+	// orders sized in the quote currency.
+	Amount      decimal.Decimal
+	Remain      decimal.Decimal
+	QuoteAmount decimal.Decimal
+	// Spent and Received are what the account paid and got from fills, net
+	// of Fee, which is charged on the received side.
+	Spent      decimal.Decimal
+	Received   decimal.Decimal
+	Fee        decimal.Decimal
+	Status     string
+	SubmitTime time.Time
+	FinishTime time.Time
+
+	own bool
+	// budget is the quote currency a market buy has left to spend
+	budget decimal.Decimal
+}
+
+// Trade represents a fill of one of the account's orders.
+type Trade struct {
+	ID            int64
+	OrderID       int64
+	ClientOrderID string
+	Pair          string
+	Side          string
+	Price         decimal.Decimal
+	Amount        decimal.Decimal
+	Fee           decimal.Decimal
+	Time          time.Time
+}
+
+// Transfer represents a deposit or withdrawal in transHistory.
+type Transfer struct {
+	ID        string
+	Currency  string
+	Type      string
+	Status    string
+	Amount    decimal.Decimal
+	Fee       decimal.Decimal
+	Network   string
+	Address   string
+	Username  string
+	Memo      string
+	RequestID string
+	TX        string
+	Time      time.Time
+}
+
+// Downline represents a user referred by the account.
+type Downline struct {
+	Name       string
+	Username   string
+	Email      string
+	Registered time.Time
+}
+
+type fault struct {
+	status  int
+	code    string
+	message string
+}
+
+// Server is a fake /tapi endpoint. The exported settings may be changed
+// before requests are made; the account state is changed through methods.
+type Server struct {
+	*httptest.Server
+
+	Key    string
+	Secret string
+	// Permissions granted to the key; all of them by default.
+	Permissions []string
+	// FeeRate is charged on what each fill delivers to the account.
+	FeeRate decimal.Decimal
+	// Now is the server clock used for server_time and timestamp auth.
+	Now func() time.Time
+
+	UserID   string
+	Name     string
+	Email    string
+	Username string
+	// Addresses are the account's own deposit addresses per currency;
+	// withdrawing to one of them is refused.
+	Addresses map[string]string
+	// Networks lists the valid withdraw networks per currency. Currencies
+	// without an entry accept any network.
+	Networks     map[string][]string
+	WithdrawFees map[string]decimal.Decimal
+	// Partner allows createVoucher.
+	Partner bool
+
+	mu        sync.Mutex
+	lastNonce int64
+	balances  map[string]decimal.Decimal
+	holds     map[string]decimal.Decimal
+	orders    []*Order
+	trades    []Trade
+	withdraws []Transfer
+	deposits  []Transfer
+	downlines []Downline
+	faults    map[string][]fault
+	nextID    int64
+}
+
+// NewServer starts a fake /tapi accepting requests signed with apiKey and
+// apiSecret. Close it when done.
+func NewServer(apiKey, apiSecret string) *Server {
+	s := &Server{
+		Key:          apiKey,
+		Secret:       apiSecret,
+		Permissions:  []string{PermView, PermTrade, PermWithdraw},
+		Now:          time.Now,
+		UserID:       "100001",
+		Name:         "Test User",
+		Email:        "test@example.com",
+		Username:     "testuser",
+		Addresses:    make(map[string]string),
+		Networks:     make(map[string][]string),
+		WithdrawFees: make(map[string]decimal.Decimal),
+		balances:     make(map[string]decimal.Decimal),
+		holds:        make(map[string]decimal.Decimal),
+		faults:       make(map[string][]fault),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoint returns the URL of the fake /tapi.
+func (s *Server) Endpoint() string {
+	return s.URL + "/tapi"
+}
+
+// IndodaxClient returns a client signed with the server's credentials and
+// pointed at Endpoint.
+func (s *Server) IndodaxClient() *client.IndodaxClient {
+	c := client.NewClient(s.Key, s.Secret, s.Client())
+	c.SetBaseURL(s.Endpoint())
+	return c
+}
+
+// SetBalance sets the available balance of currency.
+func (s *Server) SetBalance(currency string, amount decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[currency] = amount
+}
+
+// Balance returns the available and held balance of currency.
+func (s *Server) Balance(currency string) (available, hold decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balances[currency], s.holds[currency]
+}
+
+// Deposit credits currency and records the deposit in transHistory.
+func (s *Server) Deposit(currency string, amount decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.balances[currency] = s.balances[currency].Add(amount)
+	s.deposits = append(s.deposits, Transfer{
+		ID:       strconv.FormatInt(s.nextID, 10),
+		Currency: currency,
+		Type:     "deposit",
+		Status:   "success",
+		Amount:   amount,
+		TX:       fmt.Sprintf("deposit-%d", s.nextID),
+		Time:     s.Now(),
+	})
+}
+
+// AddBookOrder places a limit order for another trader. It first fills any
+// of the account's open orders it crosses and rests with the remainder.
+func (s *Server) AddBookOrder(pair, side string, price, amount decimal.Decimal) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.newOrder(pair, side, "limit", price, amount)
+	s.match(o)
+	if o.Remain.IsZero() {
+		o.Status = StatusFilled
+		o.FinishTime = s.Now()
+	}
+	return o.ID
+}
+
+// AddDownline adds a referred user for listDownline and checkDownline.
+func (s *Server) AddDownline(name, username, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downlines = append(s.downlines, Downline{Name: name, Username: username, Email: email, Registered: s.Now()})
+}
+
+// Orders returns copies of the account's orders, oldest first.
+func (s *Server) Orders() []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Order
+	for _, o := range s.orders {
+		if o.own {
+			out = append(out, *o)
+		}
+	}
+	return out
+}
+
+// Trades returns the account's fills, oldest first.
+func (s *Server) Trades() []Trade {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Trade(nil), s.trades...)
+}
+
+// Withdrawals returns the account's withdrawals and vouchers, oldest first.
+func (s *Server) Withdrawals() []Transfer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Transfer(nil), s.withdraws...)
+}
+
+// Fail makes the next call of method answer with status and an Indodax
+// error body instead of being processed. Faults queue up per method.
+func (s *Server) Fail(method string, status int, code, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = append(s.faults[method], fault{status, code, message})
+}
+
+// apiError is a failed call, written as success 0 with error and
+// error_code.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string { return e.message }
+
+func fail(code, format string, args ...interface{}) error {
+	return &apiError{status: http.StatusOK, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+var errInvalidCredentials = &apiError{
+	status:  http.StatusOK,
+	code:    "invalid_credentials",
+	message: "Invalid credentials. API not found or session has expired.",
+}
+
+type object = map[string]interface{}
+
+type handler struct {
+	perm string
+	fn   func(*Server, url.Values) (object, error)
+}
+
+var handlers = map[string]handler{
+	"getInfo":                 {PermView, (*Server).getInfo},
+	"transHistory":            {PermView, (*Server).transHistory},
+	"trade":                   {PermTrade, (*Server).trade},
+	"tradeHistory":            {PermView, (*Server).tradeHistory},
+	"openOrders":              {PermView, (*Server).openOrders},
+	"orderHistory":            {PermView, (*Server).orderHistory},
+	"getOrder":                {PermView, (*Server).getOrder},
+	"getOrderByClientOrderId": {PermView, (*Server).getOrderByClientOrderID},
+	"cancelOrder":             {PermTrade, (*Server).cancelOrder},
+	"cancelByClientOrderId":   {PermTrade, (*Server).cancelByClientOrderID},
+	"withdrawFee":             {PermWithdraw, (*Server).withdrawFee},
+	"withdrawCoin":            {PermWithdraw, (*Server).withdrawCoin},
+	"listDownline":            {PermView, (*Server).listDownline},
+	"checkDownline":           {PermView, (*Server).checkDownline},
+	"createVoucher":           {PermWithdraw, (*Server).createVoucher},
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/tapi" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, &apiError{status: http.StatusMethodNotAllowed, message: "Method not allowed"})
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, &apiError{status: http.StatusBadRequest, message: err.Error()})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, fail("", "Invalid request body"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authenticate(r.Header, body, form); err != nil {
+		writeError(w, err)
+		return
+	}
+	method := form.Get("method")
+	if q := s.faults[method]; len(q) > 0 {
+		s.faults[method] = q[1:]
+		writeError(w, &apiError{status: q[0].status, code: q[0].code, message: q[0].message})
+		return
+	}
+	h, ok := handlers[method]
+	if !ok {
+		writeError(w, fail("", "Invalid method %q", method))
+		return
+	}
+	if !s.allowed(h.perm) {
+		writeError(w, fail("", "No permission to access %s", method))
+		return
+	}
+	res, err := h.fn(s, form)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res["success"] = 1
+	writeJSON(w, http.StatusOK, res)
+}
+
+// authenticate checks the Key and Sign headers against the raw body, then
+// the nonce, which must exceed the last one accepted, or the timestamp and
+// recvWindow.
+func (s *Server) authenticate(h http.Header, body []byte, form url.Values) error {
+	mac := hmac.New(sha512.New, []byte(s.Secret))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+	if h.Get("Key") != s.Key || !hmac.Equal([]byte(strings.ToLower(h.Get("Sign"))), []byte(want)) {
+		return errInvalidCredentials
+	}
+
+	if v := form.Get("nonce"); v != "" {
+		nonce, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || nonce <= s.lastNonce {
+			return fail("invalid_nonce", "Invalid nonce. Last nonce was %d", s.lastNonce)
+		}
+		s.lastNonce = nonce
+		return nil
+	}
+	v := form.Get("timestamp")
+	if v == "" {
+		return fail("invalid_nonce", "Nonce or timestamp is required")
+	}
+	ts, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fail("invalid_timestamp", "Invalid timestamp")
+	}
+	window := int64(5000)
+	if w := form.Get("recvWindow"); w != "" {
+		if window, err = strconv.ParseInt(w, 10, 64); err != nil || window <= 0 {
+			return fail("invalid_timestamp", "Invalid recvWindow")
+		}
+	}
+	now := s.Now().UnixMilli()
+	if ts > now+1000 || now > ts+window {
+		return fail("invalid_timestamp", "Request timestamp %d is outside the recvWindow, server time is %d", ts, now)
+	}
+	return nil
+}
+
+func (s *Server) allowed(perm string) bool {
+	for _, p := range s.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = &apiError{status: http.StatusInternalServerError, message: err.Error()}
+	}
+	writeJSON(w, e.status, object{"success": 0, "error": e.message, "error_code": e.code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
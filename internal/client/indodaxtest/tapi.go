@@ -0,0 +1,757 @@
+package indodaxtest
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const dateLayout = "2006-01-02"
+
+var clientOrderIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+func unix(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// number writes d as a JSON number rather than decimal's quoted string.
+func number(d decimal.Decimal) json.Number {
+	return json.Number(d.String())
+}
+
+// decimalParam returns the first of keys present in f.
+func decimalParam(f url.Values, keys ...string) (decimal.Decimal, error) {
+	for _, k := range keys {
+		if v := f.Get(k); v != "" {
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return decimal.Zero, fail("", "Invalid %s", k)
+			}
+			return d, nil
+		}
+	}
+	return decimal.Zero, nil
+}
+
+func intParam(f url.Values, key string, def int64) (int64, error) {
+	v := f.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fail("", "Invalid %s", key)
+	}
+	return n, nil
+}
+
+func (s *Server) pair(f url.Values) (pair, base, quote string, err error) {
+	pair = f.Get("pair")
+	if pair == "" {
+		pair = "btc_idr"
+	}
+	base, quote, ok := splitPair(pair)
+	if !ok {
+		return "", "", "", fail("", "Invalid pair")
+	}
+	return pair, base, quote, nil
+}
+
+func (s *Server) balanceJSON() (balance, hold object) {
+	balance, hold = object{}, object{}
+	for cur, v := range s.balances {
+		balance[cur] = amount(cur, v)
+		hold[cur] = amount(cur, decimal.Zero)
+	}
+	for cur, v := range s.holds {
+		hold[cur] = amount(cur, v)
+		if _, ok := balance[cur]; !ok {
+			balance[cur] = amount(cur, decimal.Zero)
+		}
+	}
+	return balance, hold
+}
+
+func (s *Server) getInfo(f url.Values) (object, error) {
+	balance, hold := s.balanceJSON()
+	network := object{}
+	memo := object{}
+	for cur, nets := range s.Networks {
+		network[cur] = nets
+		required := object{}
+		for _, n := range nets {
+			required[n] = false
+		}
+		memo[cur] = required
+	}
+	withdraw := 0
+	if s.allowed(PermWithdraw) {
+		withdraw = 1
+	}
+	return object{"return": object{
+		"server_time":         s.Now().Unix(),
+		"balance":             balance,
+		"balance_hold":        hold,
+		"address":             s.Addresses,
+		"network":             network,
+		"memo_is_required":    memo,
+		"user_id":             s.UserID,
+		"name":                s.Name,
+		"email":               s.Email,
+		"profile_picture":     nil,
+		"verification_status": "verified",
+		"gauth_enable":        false,
+		"withdraw_status":     withdraw,
+	}}, nil
+}
+
+func (s *Server) transHistory(f url.Values) (object, error) {
+	now := s.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, 0, -7)
+	var err error
+	if v := f.Get("end"); v != "" {
+		if end, err = time.ParseInLocation(dateLayout, v, now.Location()); err != nil {
+			return nil, fail("invalid_date", "date format must be formatted yyyy-mm-dd")
+		}
+		if f.Get("start") == "" {
+			start = end.AddDate(0, 0, -7)
+		}
+	}
+	if v := f.Get("start"); v != "" {
+		if start, err = time.ParseInLocation(dateLayout, v, now.Location()); err != nil {
+			return nil, fail("invalid_date", "date format must be formatted yyyy-mm-dd")
+		}
+	}
+	if start.After(end) {
+		return nil, fail("invalid_date", "start date must be less then end date")
+	}
+	if end.Sub(start) > 7*24*time.Hour {
+		return nil, fail("invalid_date", "range date can't more than 7 days")
+	}
+	end = end.AddDate(0, 0, 1)
+
+	list := func(transfers []Transfer, idKey string) object {
+		out := object{}
+		for i := len(transfers) - 1; i >= 0; i-- {
+			t := transfers[i]
+			if t.Time.Before(start) || !t.Time.Before(end) {
+				continue
+			}
+			item := object{
+				"status":       t.Status,
+				"type":         t.Type,
+				"fee":          amount(t.Currency, t.Fee),
+				"amount":       amount(t.Currency, t.Amount.Sub(t.Fee)),
+				"submit_time":  unix(t.Time),
+				"success_time": unix(t.Time),
+				idKey:          t.ID,
+				"tx":           t.TX,
+			}
+			if t.Currency == "idr" {
+				item["rp"] = amount(t.Currency, t.Amount)
+			} else {
+				item[t.Currency] = amount(t.Currency, t.Amount)
+			}
+			items, _ := out[t.Currency].([]object)
+			out[t.Currency] = append(items, item)
+		}
+		return out
+	}
+	return object{"return": object{
+		"withdraw": list(s.withdraws, "withdraw_id"),
+		"deposit":  list(s.deposits, "deposit_id"),
+	}}, nil
+}
+
+// trade places an order. Without order_type an order with a price is a
+// limit order and one without is a market order.
+func (s *Server) trade(f url.Values) (object, error) {
+	pair, base, quote, err := s.pair(f)
+	if err != nil {
+		return nil, err
+	}
+	side := f.Get("type")
+	if side != "buy" && side != "sell" {
+		return nil, fail("", "Invalid type")
+	}
+	price, err := decimalParam(f, "price")
+	if err != nil {
+		return nil, err
+	}
+	coins, err := decimalParam(f, base, "btc")
+	if err != nil {
+		return nil, err
+	}
+	funds, err := decimalParam(f, quote, "idr")
+	if err != nil {
+		return nil, err
+	}
+	orderType := f.Get("order_type")
+	if orderType == "" {
+		orderType = "market"
+		if price.IsPositive() {
+			orderType = "limit"
+		}
+	}
+	tif := f.Get("time_in_force")
+	if tif == "" {
+		tif = "GTC"
+	}
+	if tif != "GTC" && tif != "MOC" {
+		return nil, fail("", "Invalid time_in_force")
+	}
+	clientID := f.Get("client_order_id")
+	if clientID != "" {
+		if !clientOrderIDPattern.MatchString(clientID) {
+			return nil, fail("", "Invalid client_order_id")
+		}
+		if s.byClientID(clientID) != nil {
+			return nil, fail("", "client order id %s already exists", clientID)
+		}
+	}
+
+	var need decimal.Decimal
+	switch orderType {
+	case "limit":
+		if !price.IsPositive() {
+			return nil, fail("", "Price is required for limit order")
+		}
+		if side == "buy" && funds.IsPositive() {
+			if f.Get("order_type") == "limit" {
+				return nil, fail("", "Limit buy order must be sized in %s, not %s", base, quote)
+			}
+			coins = funds.Div(price).Truncate(coinPlaces)
+		}
+		if tif == "MOC" && s.crosses(pair, side, price) {
+			return nil, fail("", "Order cancelled because it’s not maker.")
+		}
+		need = coins
+		if side == "buy" {
+			need = coins.Mul(price)
+		}
+	case "market":
+		if tif != "GTC" {
+			return nil, fail("", "time_in_force is only valid for limit orders")
+		}
+		if side == "buy" {
+			if !funds.IsPositive() {
+				return nil, fail("", "Market buy order only supports amount in %s", quote)
+			}
+			need = funds
+		} else {
+			need = coins
+		}
+	default:
+		return nil, fail("", "Invalid order_type")
+	}
+	if side == "sell" || orderType == "limit" {
+		if !coins.IsPositive() {
+			return nil, fail("", "Invalid %s amount", base)
+		}
+	}
+	pay := quote
+	if side == "sell" {
+		pay = base
+	}
+	if s.balances[pay].LessThan(need) {
+		return nil, fail("", "Insufficient balance.")
+	}
+
+	o := s.newOrder(pair, side, orderType, price, coins)
+	o.own = true
+	o.ClientOrderID = clientID
+	o.TimeInForce = tif
+	if side == "buy" && funds.IsPositive() {
+		o.QuoteAmount = funds
+		o.budget = funds
+	}
+	s.match(o)
+	if orderType == "market" {
+		o.Status = StatusFilled
+		if o.Spent.IsZero() {
+			o.Status = StatusCancelled
+		}
+		o.FinishTime = s.Now()
+	} else if o.Status == StatusOpen {
+		s.hold(o)
+	}
+
+	res := object{
+		"order_id":        o.ID,
+		"client_order_id": o.ClientOrderID,
+		"fee":             number(o.Fee),
+	}
+	if side == "buy" {
+		remain := o.Remain.Mul(o.Price)
+		if orderType == "market" {
+			remain = o.budget
+		}
+		res["receive_"+base] = amount(base, o.Received)
+		res["spend_rp"] = number(o.Spent)
+		res["remain_rp"] = number(remain)
+	} else {
+		res["receive_"+quote] = amount(quote, o.Received)
+		res["sold_"+base] = amount(base, o.Spent)
+		res["remain_"+base] = amount(base, o.Remain)
+	}
+	return object{"return": res}, nil
+}
+
+func (s *Server) tradeHistory(f url.Values) (object, error) {
+	pair, base, _, err := s.pair(f)
+	if err != nil {
+		return nil, err
+	}
+	count, err := intParam(f, "count", 1000)
+	if err != nil {
+		return nil, err
+	}
+	fromID, err := intParam(f, "from_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	if fromID == 0 {
+		if fromID, err = intParam(f, "from", 0); err != nil {
+			return nil, err
+		}
+	}
+	endID, err := intParam(f, "end_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	since, err := intParam(f, "since", 0)
+	if err != nil {
+		return nil, err
+	}
+	until, err := intParam(f, "end", 0)
+	if err != nil {
+		return nil, err
+	}
+	orderID, err := intParam(f, "order_id", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []Trade
+	for _, t := range s.trades {
+		switch {
+		case t.Pair != pair,
+			orderID != 0 && t.OrderID != orderID,
+			fromID != 0 && t.ID < fromID,
+			endID != 0 && t.ID > endID,
+			since != 0 && t.Time.Unix() < since,
+			until != 0 && t.Time.Unix() > until:
+			continue
+		}
+		trades = append(trades, t)
+	}
+	if f.Get("order") != "asc" {
+		sort.SliceStable(trades, func(i, j int) bool { return trades[i].ID > trades[j].ID })
+	}
+	if int64(len(trades)) > count {
+		trades = trades[:count]
+	}
+	out := make([]object, 0, len(trades))
+	for _, t := range trades {
+		out = append(out, object{
+			"trade_id":        strconv.FormatInt(t.ID, 10),
+			"order_id":        strconv.FormatInt(t.OrderID, 10),
+			"type":            t.Side,
+			base:              amount(base, t.Amount),
+			"price":           t.Price.String(),
+			"fee":             t.Fee.String(),
+			"trade_time":      unix(t.Time),
+			"client_order_id": t.ClientOrderID,
+		})
+	}
+	return object{"return": object{"trades": out}}, nil
+}
+
+// orderJSON renders one of the account's orders. Orders sized in the quote
+// currency report order_<quote> and remain_<quote>; getOrder names them
+// order_rp and remain_rp through quoteKey.
+func orderJSON(o *Order, quoteKey string) object {
+	base, quote, _ := splitPair(o.Pair)
+	if quoteKey == "" {
+		quoteKey = quote
+	}
+	res := object{
+		"order_id":        strconv.FormatInt(o.ID, 10),
+		"client_order_id": o.ClientOrderID,
+		"submit_time":     unix(o.SubmitTime),
+		"price":           o.Price.String(),
+		"type":            o.Side,
+		"order_type":      o.OrderType,
+	}
+	if o.QuoteAmount.IsPositive() {
+		remain := o.Remain.Mul(o.Price)
+		if o.OrderType == "market" {
+			remain = o.budget
+		}
+		res["order_"+quoteKey] = amount(quote, o.QuoteAmount)
+		res["remain_"+quoteKey] = amount(quote, remain)
+	} else {
+		res["order_"+base] = amount(base, o.Amount)
+		res["remain_"+base] = amount(base, o.Remain)
+	}
+	return res
+}
+
+// finished adds the fields orderHistory and getOrder report beyond those
+// of an open order.
+func finished(o *Order, res object) object {
+	base, quote, _ := splitPair(o.Pair)
+	got := base
+	if o.Side == "sell" {
+		got = quote
+	}
+	res["finish_time"] = unix(o.FinishTime)
+	res["status"] = o.Status
+	res["receive_"+got] = amount(got, o.Received)
+	return res
+}
+
+func (s *Server) openOrders(f url.Values) (object, error) {
+	if f.Get("pair") == "" {
+		byPair := object{}
+		for _, o := range s.orders {
+			if o.own && o.Status == StatusOpen {
+				list, _ := byPair[o.Pair].([]object)
+				byPair[o.Pair] = append(list, orderJSON(o, ""))
+			}
+		}
+		return object{"return": object{"orders": byPair}}, nil
+	}
+	pair, _, _, err := s.pair(f)
+	if err != nil {
+		return nil, err
+	}
+	orders := []object{}
+	for _, o := range s.orders {
+		if o.own && o.Status == StatusOpen && o.Pair == pair {
+			orders = append(orders, orderJSON(o, ""))
+		}
+	}
+	return object{"return": object{"orders": orders}}, nil
+}
+
+func (s *Server) orderHistory(f url.Values) (object, error) {
+	pair, _, _, err := s.pair(f)
+	if err != nil {
+		return nil, err
+	}
+	count, err := intParam(f, "count", 1000)
+	if err != nil {
+		return nil, err
+	}
+	from, err := intParam(f, "from", 0)
+	if err != nil {
+		return nil, err
+	}
+	orders := []object{}
+	for i := len(s.orders) - 1; i >= 0 && int64(len(orders)) < count; i-- {
+		o := s.orders[i]
+		if o.own && o.Pair == pair && o.ID >= from {
+			orders = append(orders, finished(o, orderJSON(o, "")))
+		}
+	}
+	return object{"return": object{"orders": orders}}, nil
+}
+
+func (s *Server) byID(pair string, id int64) *Order {
+	for _, o := range s.orders {
+		if o.own && o.ID == id && o.Pair == pair {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Server) byClientID(id string) *Order {
+	for _, o := range s.orders {
+		if o.own && o.ClientOrderID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Server) lookup(f url.Values) (*Order, error) {
+	if id := f.Get("client_order_id"); id != "" {
+		if o := s.byClientID(id); o != nil {
+			return o, nil
+		}
+		return nil, fail("", "Order not found")
+	}
+	pair, _, _, err := s.pair(f)
+	if err != nil {
+		return nil, err
+	}
+	id, err := intParam(f, "order_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	if o := s.byID(pair, id); o != nil {
+		return o, nil
+	}
+	return nil, fail("", "Order not found")
+}
+
+func (s *Server) getOrder(f url.Values) (object, error) {
+	o, err := s.lookup(f)
+	if err != nil {
+		return nil, err
+	}
+	return object{"return": object{"order": finished(o, orderJSON(o, "rp"))}}, nil
+}
+
+func (s *Server) getOrderByClientOrderID(f url.Values) (object, error) {
+	if f.Get("client_order_id") == "" {
+		return nil, fail("", "client_order_id is required")
+	}
+	return s.getOrder(f)
+}
+
+func (s *Server) cancelOrder(f url.Values) (object, error) {
+	o, err := s.lookup(f)
+	if err != nil {
+		return nil, err
+	}
+	if t := f.Get("type"); t != "" && t != o.Side {
+		return nil, fail("", "Invalid type")
+	}
+	if o.Status != StatusOpen {
+		return nil, fail("", "Order %d is already %s", o.ID, o.Status)
+	}
+	s.release(o)
+	o.Status = StatusCancelled
+	o.FinishTime = s.Now()
+
+	balance, hold := s.balanceJSON()
+	for cur, v := range hold {
+		balance["frozen_"+cur] = v
+	}
+	return object{"return": object{
+		"order_id":        o.ID,
+		"client_order_id": o.ClientOrderID,
+		"type":            o.Side,
+		"pair":            o.Pair,
+		"balance":         balance,
+	}}, nil
+}
+
+func (s *Server) cancelByClientOrderID(f url.Values) (object, error) {
+	if f.Get("client_order_id") == "" {
+		return nil, fail("", "client_order_id is required")
+	}
+	return s.cancelOrder(f)
+}
+
+func (s *Server) checkNetwork(currency, network string) error {
+	nets, ok := s.Networks[currency]
+	if !ok || network == "" {
+		return nil
+	}
+	for _, n := range nets {
+		if n == network {
+			return nil
+		}
+	}
+	return fail("", "Invalid network, please fill with one of this %s", strings.Join(nets, ", "))
+}
+
+func (s *Server) withdrawFee(f url.Values) (object, error) {
+	currency := f.Get("currency")
+	if currency == "" {
+		return nil, fail("", "currency is required")
+	}
+	if err := s.checkNetwork(currency, f.Get("network")); err != nil {
+		return nil, err
+	}
+	return object{"return": object{
+		"server_time":  s.Now().Unix(),
+		"withdraw_fee": number(s.WithdrawFees[currency]),
+		"currency":     currency,
+	}}, nil
+}
+
+// withdrawCoin sends coins to an address, or to an Indodax user without a
+// fee when withdraw_input_method is username. Its reply is not wrapped in
+// return.
+func (s *Server) withdrawCoin(f url.Values) (object, error) {
+	currency := f.Get("currency")
+	if currency == "" || currency == "idr" {
+		return nil, fail("", "Invalid currency")
+	}
+	value, err := decimalParam(f, "withdraw_amount")
+	if err != nil {
+		return nil, err
+	}
+	if !value.IsPositive() {
+		return nil, fail("", "Can't make withdrawal with amount 0, input a larger withdraw_amount value")
+	}
+	if f.Get("request_id") == "" {
+		return nil, fail("", "request_id is required")
+	}
+
+	t := Transfer{
+		Currency:  currency,
+		Type:      "withdraw",
+		Status:    "approved",
+		Amount:    value,
+		Memo:      f.Get("withdraw_memo"),
+		RequestID: f.Get("request_id"),
+		Time:      s.Now(),
+	}
+	if f.Get("withdraw_input_method") == "username" {
+		t.Username = f.Get("withdraw_username")
+		if t.Username == "" {
+			return nil, fail("", "Username is not found!")
+		}
+		if t.Username == s.Username {
+			return nil, fail("", "Please use recipient address other than your Indodax account address")
+		}
+		t.Status = "wait"
+	} else {
+		t.Network = f.Get("network")
+		t.Address = f.Get("withdraw_address")
+		if t.Address == "" {
+			return nil, fail("", "withdraw_address is required")
+		}
+		if t.Address == s.Addresses[currency] {
+			return nil, fail("", "Please use recipient address other than your Indodax account address")
+		}
+		if err := s.checkNetwork(currency, t.Network); err != nil {
+			return nil, err
+		}
+		t.Fee = s.WithdrawFees[currency]
+	}
+	if value.LessThan(t.Fee) {
+		return nil, fail("", "Withdraw amount must be more than fee %s", amount(currency, t.Fee))
+	}
+	if s.balances[currency].LessThan(value) {
+		return nil, fail("", "Insufficient balance.")
+	}
+	s.balances[currency] = s.balances[currency].Sub(value)
+	s.nextID++
+	t.ID = currency + "-" + strconv.FormatInt(s.nextID, 10)
+	s.withdraws = append(s.withdraws, t)
+
+	res := object{
+		"status":            t.Status,
+		"withdraw_currency": currency,
+		"withdraw_address":  t.Address,
+		"withdraw_amount":   amount(currency, value),
+		"fee":               amount(currency, t.Fee),
+		"amount_after_fee":  amount(currency, value.Sub(t.Fee)),
+		"submit_time":       unix(t.Time),
+		"withdraw_id":       t.ID,
+		"txid":              "",
+	}
+	if t.Username != "" {
+		res["withdraw_username"] = t.Username
+	}
+	return res, nil
+}
+
+func (s *Server) listDownline(f url.Values) (object, error) {
+	page, err := intParam(f, "page", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := intParam(f, "limit", 200)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 || limit < 1 {
+		return nil, fail("", "page and limit must be positive")
+	}
+	total := int64(len(s.downlines))
+	data := []object{}
+	for i := (page - 1) * limit; i < total && i < page*limit; i++ {
+		d := s.downlines[i]
+		data = append(data, object{
+			"name":              d.Name,
+			"username":          d.Username,
+			"registration_date": d.Registered.Format("2-Jan-06 15:04"),
+			"email_verified":    true,
+			"id_verified":       true,
+			"level":             "n/a",
+			"end":               "n/a",
+			"start":             "n/a",
+		})
+	}
+	return object{"return": object{
+		"curr_page":           page,
+		"total_page":          (total + limit - 1) / limit,
+		"total_data_per_page": len(data),
+		"total":               total,
+		"data":                data,
+	}}, nil
+}
+
+// checkDownline answers outside return with is_downline "1" or "0".
+func (s *Server) checkDownline(f url.Values) (object, error) {
+	email := f.Get("email")
+	if email == "" {
+		return nil, fail("", "email is required")
+	}
+	found := "0"
+	for _, d := range s.downlines {
+		if strings.EqualFold(d.Email, email) {
+			found = "1"
+			break
+		}
+	}
+	return object{"is_downline": found}, nil
+}
+
+// createVoucher pays an IDR voucher from the balance; it is booked as an
+// IDR coupon withdrawal.
+func (s *Server) createVoucher(f url.Values) (object, error) {
+	if !s.Partner {
+		return nil, fail("permission_denied", "No permission, only partners can create vouchers")
+	}
+	value, err := intParam(f, "amount", 0)
+	if err != nil {
+		return nil, err
+	}
+	if value < 1000 {
+		return nil, fail("", "Minimum voucher amount is 1000")
+	}
+	if f.Get("to_email") == "" {
+		return nil, fail("", "to_email is required")
+	}
+	rp := decimal.NewFromInt(value)
+	if s.balances["idr"].LessThan(rp) {
+		return nil, fail("", "Insufficient balance.")
+	}
+	s.balances["idr"] = s.balances["idr"].Sub(rp)
+	s.nextID++
+	t := Transfer{
+		ID:       strconv.FormatInt(s.nextID, 10),
+		Currency: "idr",
+		Type:     "coupon",
+		Status:   "success",
+		Amount:   rp,
+		TX:       "BTC-IDR-" + strconv.FormatInt(s.nextID, 10),
+		Time:     s.Now(),
+	}
+	s.withdraws = append(s.withdraws, t)
+	return object{
+		"withdraw_id": s.nextID,
+		"rp":          value,
+		"submit_time": unix(t.Time),
+		"voucher":     t.TX,
+	}, nil
+}
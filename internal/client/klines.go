@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxKlineLimit is the most klines Binance returns for a single request.
+const MaxKlineLimit = 1000
+
+// Gap is a run of candles missing from an otherwise continuous series, for
+// example while a market was halted. From and To are the open times of the
+// first and last missing candle.
+type Gap struct {
+	From time.Time
+	To   time.Time
+}
+
+// Missing returns how many candles of length dur the gap spans.
+func (g Gap) Missing(dur time.Duration) int {
+	return int(g.To.Sub(g.From)/dur) + 1
+}
+
+// Request weights Binance charges per endpoint. The binancetest fake uses
+// the same values.
+const (
+	KlinesWeight       = 2
+	Ticker24hWeight    = 2
+	ExchangeInfoWeight = 20
+)
+
+// Klines fetches the last limit klines of the given interval, oldest first.
+// Limits above MaxKlineLimit are fetched in pages.
+func (c *BinanceClient) Klines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
+	if limit > MaxKlineLimit {
+		dur, err := IntervalDuration(interval)
+		if err != nil {
+			return nil, err
+		}
+		end := time.Now()
+		klines, _, err := c.KlinesBetween(ctx, symbol, interval, end.Add(-time.Duration(limit)*dur), end)
+		if err != nil {
+			return nil, err
+		}
+		if len(klines) > limit {
+			klines = klines[len(klines)-limit:]
+		}
+		return klines, nil
+	}
+	q := url.Values{}
+	q.Add("limit", strconv.Itoa(limit))
+	return c.klines(ctx, symbol, interval, q)
+}
+
+// KlinesRange fetches up to limit klines (Binance caps this at 1000)
+// opening between start and end, oldest first.
+func (c *BinanceClient) KlinesRange(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	q := url.Values{}
+	q.Add("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+	q.Add("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	q.Add("limit", strconv.Itoa(limit))
+	return c.klines(ctx, symbol, interval, q)
+}
+
+func (c *BinanceClient) klines(ctx context.Context, symbol, interval string, q url.Values) ([]Kline, error) {
+	if _, err := IntervalDuration(interval); err != nil {
+		return nil, err
+	}
+	q.Add("symbol", symbol)
+	q.Add("interval", interval)
+	var raw json.RawMessage
+	if err := c.get(ctx, "/api/v3/klines", q, KlinesWeight, &raw); err != nil {
+		return nil, fmt.Errorf("klines %s %s: %w", symbol, interval, err)
+	}
+	return ParseKlines(raw)
+}
+
+// KlinesBefore fetches the last n klines of the given interval opening no
+// later than end, oldest first, paging back through Binance's per-request
+// limit. Fewer are returned only when the symbol has no older history.
+func (c *BinanceClient) KlinesBefore(ctx context.Context, symbol, interval string, end time.Time, n int) ([]Kline, error) {
+	var klines []Kline
+	for len(klines) < n {
+		limit := n - len(klines)
+		if limit > MaxKlineLimit {
+			limit = MaxKlineLimit
+		}
+		q := url.Values{}
+		q.Add("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+		q.Add("limit", strconv.Itoa(limit))
+		page, err := c.klines(ctx, symbol, interval, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(klines) > 0 {
+			for len(page) > 0 && !page[len(page)-1].OpenTime.Before(klines[0].OpenTime) {
+				page = page[:len(page)-1]
+			}
+		}
+		if len(page) == 0 {
+			break
+		}
+		klines = append(page, klines...)
+		end = page[0].OpenTime.Add(-time.Millisecond)
+	}
+	return klines, nil
+}
+
+// ParseKlines decodes a klines reply, either straight from /api/v3/klines or
+// saved from it as a fixture.
+func ParseKlines(data []byte) ([]Kline, error) {
+	var raw [][]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal klines: %w", err)
+	}
+	klines := make([]Kline, len(raw))
+	for i, row := range raw {
+		var err error
+		if klines[i], err = parseKline(row); err != nil {
+			return nil, err
+		}
+	}
+	return klines, nil
+}
+
+// KlinesBetween fetches every kline of the given interval opening between
+// start and end, oldest first, paging through Binance's per-request limit.
+// Candles repeated across pages are dropped, and any hole in the returned
+// series is reported as a Gap. History before the symbol was listed is
+// simply absent and is not a gap.
+func (c *BinanceClient) KlinesBetween(ctx context.Context, symbol, interval string, start, end time.Time) ([]Kline, []Gap, error) {
+	dur, err := IntervalDuration(interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	var klines []Kline
+	var gaps []Gap
+	for !start.After(end) {
+		page, err := c.KlinesRange(ctx, symbol, interval, start, end, MaxKlineLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		var added int
+		klines, gaps, added = appendKlines(klines, gaps, page, dur)
+		// an empty or fully repeated page means there is nothing further
+		if added == 0 {
+			break
+		}
+		start = klines[len(klines)-1].OpenTime.Add(dur)
+	}
+	return klines, gaps, nil
+}
+
+// appendKlines adds the candles of page that are newer than the last one in
+// klines, recording a Gap wherever consecutive candles are more than dur
+// apart. It returns how many candles were added.
+func appendKlines(klines []Kline, gaps []Gap, page []Kline, dur time.Duration) ([]Kline, []Gap, int) {
+	added := 0
+	for _, k := range page {
+		if n := len(klines); n > 0 {
+			last := klines[n-1].OpenTime
+			if !k.OpenTime.After(last) {
+				continue
+			}
+			if want := last.Add(dur); k.OpenTime.After(want) {
+				gaps = append(gaps, Gap{From: want, To: k.OpenTime.Add(-dur)})
+			}
+		}
+		klines = append(klines, k)
+		added++
+	}
+	return klines, gaps, added
+}
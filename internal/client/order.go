@@ -0,0 +1,226 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// OrderSide is the type parameter of trade.
+type OrderSide string
+
+const (
+	Buy  OrderSide = "buy"
+	Sell OrderSide = "sell"
+)
+
+// OrderType is the order_type parameter of trade.
+type OrderType string
+
+const (
+	OrderLimit  OrderType = "limit"
+	OrderMarket OrderType = "market"
+	// OrderStopLimit is shown on the Indodax site, but the private trade API
+	// takes no stop price, so such requests are rejected before sending.
+	OrderStopLimit OrderType = "stoplimit"
+)
+
+// TimeInForce is the time_in_force parameter of limit orders.
+type TimeInForce string
+
+const (
+	// GTC rests until filled or cancelled. It is the default.
+	GTC TimeInForce = "GTC"
+	// MOC (maker or cancel) cancels the order instead of letting it take
+	// liquidity.
+	MOC TimeInForce = "MOC"
+)
+
+// ErrInvalidOrder is wrapped by the errors of OrderRequest.Validate, which
+// are caught before anything is sent.
+var ErrInvalidOrder = errors.New("indodax: invalid order")
+
+// clientOrderIDPattern is the documented client_order_id format.
+var clientOrderIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,36}$`)
+
+// OrderRequest is an order for IndodaxClient.Trade. Amount is the size in
+// the base coin and is sent under its name (btc, eth, ...); QuoteAmount is
+// the size in the quote currency and is only used by market buys.
+type OrderRequest struct {
+	Pair          string
+	Side          OrderSide
+	Type          OrderType
+	Price         decimal.Decimal
+	StopPrice     decimal.Decimal
+	Amount        decimal.Decimal
+	QuoteAmount   decimal.Decimal
+	ClientOrderID string
+	TimeInForce   TimeInForce
+}
+
+func invalidOrder(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidOrder, fmt.Sprintf(format, args...))
+}
+
+// Validate checks r against the rules of the trade API: limit orders need a
+// price and a base amount, market buys are sized in the quote currency and
+// market sells in the base coin, and MOC applies to limit orders only.
+func (r OrderRequest) Validate() error {
+	base, quote, ok := strings.Cut(r.Pair, "_")
+	if !ok || base == "" || quote == "" {
+		return invalidOrder("pair %q is not of the form btc_idr", r.Pair)
+	}
+	if r.Side != Buy && r.Side != Sell {
+		return invalidOrder("side %q is neither buy nor sell", r.Side)
+	}
+	if r.Price.IsNegative() || r.Amount.IsNegative() || r.QuoteAmount.IsNegative() {
+		return invalidOrder("negative price or amount")
+	}
+	if r.ClientOrderID != "" && !clientOrderIDPattern.MatchString(r.ClientOrderID) {
+		return invalidOrder("client order id %q must be 1-36 letters, digits, _ or -", r.ClientOrderID)
+	}
+	if r.TimeInForce != "" && r.TimeInForce != GTC && r.TimeInForce != MOC {
+		return invalidOrder("time in force %q is neither GTC nor MOC", r.TimeInForce)
+	}
+
+	switch r.Type {
+	case OrderLimit, "":
+		if !r.Price.IsPositive() {
+			return invalidOrder("limit order needs a price")
+		}
+		if !r.QuoteAmount.IsZero() {
+			return invalidOrder("limit %s must be sized in %s, not %s", r.Side, base, quote)
+		}
+		if !r.Amount.IsPositive() {
+			return invalidOrder("limit order needs an amount of %s", base)
+		}
+	case OrderMarket:
+		if r.TimeInForce == MOC {
+			return invalidOrder("MOC is only valid for limit orders")
+		}
+		if !r.Price.IsZero() {
+			return invalidOrder("market order takes no price")
+		}
+		if r.Side == Buy {
+			if !r.Amount.IsZero() || !r.QuoteAmount.IsPositive() {
+				return invalidOrder("market buy must be sized in %s", quote)
+			}
+		} else if !r.QuoteAmount.IsZero() || !r.Amount.IsPositive() {
+			return invalidOrder("market sell must be sized in %s", base)
+		}
+	case OrderStopLimit:
+		return invalidOrder("stop-limit orders cannot be placed through the trade API")
+	default:
+		return invalidOrder("order type %q is not supported", r.Type)
+	}
+	if !r.StopPrice.IsZero() {
+		return invalidOrder("stop price is only valid for stop-limit orders")
+	}
+	return nil
+}
+
+// params returns the trade parameters of a validated request.
+func (r OrderRequest) params() map[string]string {
+	base, quote, _ := strings.Cut(r.Pair, "_")
+	orderType := r.Type
+	if orderType == "" {
+		orderType = OrderLimit
+	}
+	params := map[string]string{
+		"pair":       r.Pair,
+		"type":       string(r.Side),
+		"order_type": string(orderType),
+	}
+	if !r.Price.IsZero() {
+		params["price"] = r.Price.String()
+	}
+	if !r.Amount.IsZero() {
+		params[base] = r.Amount.String()
+	}
+	if !r.QuoteAmount.IsZero() {
+		params[quote] = r.QuoteAmount.String()
+	}
+	if r.ClientOrderID != "" {
+		params["client_order_id"] = r.ClientOrderID
+	}
+	if r.TimeInForce != "" {
+		params["time_in_force"] = string(r.TimeInForce)
+	}
+	return params
+}
+
+// OrderBuilder assembles an OrderRequest:
+//
+//	req, err := client.NewOrder("eth_idr", client.Buy).
+//		Limit(price).Amount(amount).MakerOnly().Build()
+type OrderBuilder struct {
+	req OrderRequest
+}
+
+// NewOrder starts a limit order on pair.
+func NewOrder(pair string, side OrderSide) *OrderBuilder {
+	return &OrderBuilder{req: OrderRequest{Pair: strings.ToLower(pair), Side: side, Type: OrderLimit}}
+}
+
+// Limit makes the order a limit order at price.
+func (b *OrderBuilder) Limit(price decimal.Decimal) *OrderBuilder {
+	b.req.Type = OrderLimit
+	b.req.Price = price
+	return b
+}
+
+// Market makes the order a market order.
+func (b *OrderBuilder) Market() *OrderBuilder {
+	b.req.Type = OrderMarket
+	b.req.Price = decimal.Zero
+	return b
+}
+
+// StopLimit makes the order a stop-limit order; Build rejects it, see
+// OrderStopLimit.
+func (b *OrderBuilder) StopLimit(stop, price decimal.Decimal) *OrderBuilder {
+	b.req.Type = OrderStopLimit
+	b.req.StopPrice = stop
+	b.req.Price = price
+	return b
+}
+
+// Amount sizes the order in the base coin.
+func (b *OrderBuilder) Amount(amount decimal.Decimal) *OrderBuilder {
+	b.req.Amount = amount
+	return b
+}
+
+// QuoteAmount sizes the order in the quote currency, for market buys.
+func (b *OrderBuilder) QuoteAmount(amount decimal.Decimal) *OrderBuilder {
+	b.req.QuoteAmount = amount
+	return b
+}
+
+// ClientOrderID sets the id the order can be looked up and cancelled by.
+func (b *OrderBuilder) ClientOrderID(id string) *OrderBuilder {
+	b.req.ClientOrderID = id
+	return b
+}
+
+// TimeInForce sets GTC or MOC.
+func (b *OrderBuilder) TimeInForce(tif TimeInForce) *OrderBuilder {
+	b.req.TimeInForce = tif
+	return b
+}
+
+// MakerOnly is TimeInForce(MOC).
+func (b *OrderBuilder) MakerOnly() *OrderBuilder {
+	return b.TimeInForce(MOC)
+}
+
+// Build validates and returns the request.
+func (b *OrderBuilder) Build() (OrderRequest, error) {
+	if err := b.req.Validate(); err != nil {
+		return OrderRequest{}, err
+	}
+	return b.req, nil
+}
@@ -0,0 +1,143 @@
+package client_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+func TestOrderBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   *client.OrderBuilder
+		wantErr bool
+	}{
+		{
+			name:  "limit buy",
+			build: client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01")),
+		},
+		{
+			name: "IDR-sized limit order",
+			build: client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).
+				QuoteAmount(dec("5000000")),
+			wantErr: true,
+		},
+		{
+			name:    "limit order without a price",
+			build:   client.NewOrder("btc_idr", client.Buy).Amount(dec("0.01")),
+			wantErr: true,
+		},
+		{
+			name:    "limit order without an amount",
+			build:   client.NewOrder("btc_idr", client.Sell).Limit(dec("500000000")),
+			wantErr: true,
+		},
+		{
+			name:  "maker-only limit order",
+			build: client.NewOrder("btc_idr", client.Sell).Limit(dec("500000000")).Amount(dec("0.01")).MakerOnly(),
+		},
+		{
+			name:  "market buy sized in IDR",
+			build: client.NewOrder("btc_idr", client.Buy).Market().QuoteAmount(dec("100000")),
+		},
+		{
+			name:    "market buy without QuoteAmount",
+			build:   client.NewOrder("btc_idr", client.Buy).Market().Amount(dec("0.01")),
+			wantErr: true,
+		},
+		{
+			name:  "market sell sized in the coin",
+			build: client.NewOrder("btc_idr", client.Sell).Market().Amount(dec("0.01")),
+		},
+		{
+			name:    "market sell sized in IDR",
+			build:   client.NewOrder("btc_idr", client.Sell).Market().QuoteAmount(dec("100000")),
+			wantErr: true,
+		},
+		{
+			name:    "MOC on a market order",
+			build:   client.NewOrder("btc_idr", client.Buy).Market().QuoteAmount(dec("100000")).MakerOnly(),
+			wantErr: true,
+		},
+		{
+			name:    "unknown time in force",
+			build:   client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01")).TimeInForce("IOC"),
+			wantErr: true,
+		},
+		{
+			name:    "stop price set",
+			build:   client.NewOrder("btc_idr", client.Sell).StopLimit(dec("490000000"), dec("480000000")).Amount(dec("0.01")),
+			wantErr: true,
+		},
+		{
+			name:    "pair without an underscore",
+			build:   client.NewOrder("btcidr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01")),
+			wantErr: true,
+		},
+		{
+			name:    "negative amount",
+			build:   client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("-0.01")),
+			wantErr: true,
+		},
+		{
+			name:  "client order id of 36 characters",
+			build: limitBuy().ClientOrderID(strings.Repeat("a", 36)),
+		},
+		{
+			name:  "client order id with digits, _ and -",
+			build: limitBuy().ClientOrderID("Bot_1-2024"),
+		},
+		{
+			name:    "client order id of 37 characters",
+			build:   limitBuy().ClientOrderID(strings.Repeat("a", 37)),
+			wantErr: true,
+		},
+		{
+			name:    "client order id with a space",
+			build:   limitBuy().ClientOrderID("bot 1"),
+			wantErr: true,
+		},
+		{
+			name:    "client order id with a dot",
+			build:   limitBuy().ClientOrderID("bot.1"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := tt.build.Build()
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				if req.Pair == "" {
+					t.Error("Build returned an empty request")
+				}
+				return
+			}
+			if !errors.Is(err, client.ErrInvalidOrder) {
+				t.Fatalf("err = %v, want ErrInvalidOrder", err)
+			}
+			if req != (client.OrderRequest{}) {
+				t.Errorf("Build returned %+v along with the error", req)
+			}
+		})
+	}
+}
+
+func limitBuy() *client.OrderBuilder {
+	return client.NewOrder("btc_idr", client.Buy).Limit(dec("500000000")).Amount(dec("0.01"))
+}
+
+func TestOrderValidateDefaultsToLimit(t *testing.T) {
+	req := client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("500000000"), Amount: dec("0.01")}
+	if err := req.Validate(); err != nil {
+		t.Errorf("order without a type: %v", err)
+	}
+	req.Side = "hold"
+	if err := req.Validate(); !errors.Is(err, client.ErrInvalidOrder) {
+		t.Errorf("side %q: err = %v, want ErrInvalidOrder", req.Side, err)
+	}
+}
@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Errors wrapped by OrderRuleError, the way an order breaks a pair's rules.
+var (
+	ErrUnknownPair  = errors.New("indodax: unknown pair")
+	ErrMarketClosed = errors.New("indodax: market in maintenance or suspended")
+	ErrPriceTick    = errors.New("indodax: price is not a multiple of the tick")
+	ErrPricePlaces  = errors.New("indodax: price has too many decimals")
+	ErrAmountPlaces = errors.New("indodax: amount has too many decimals")
+	ErrBelowMinimum = errors.New("indodax: order below minimum size")
+)
+
+// OrderRuleError is an order that breaks the precision or size rules of its
+// pair. It matches Err and ErrInvalidOrder through errors.Is.
+type OrderRuleError struct {
+	Pair  string
+	Field string
+	Value decimal.Decimal
+	// Limit is the tick, the number of decimals or the minimum that was
+	// broken.
+	Limit decimal.Decimal
+	Err   error
+}
+
+func (e *OrderRuleError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%v: %s", e.Err, e.Pair)
+	}
+	return fmt.Sprintf("%v: %s %s %s (limit %s)", e.Err, e.Pair, e.Field, e.Value, e.Limit)
+}
+
+func (e *OrderRuleError) Unwrap() error { return e.Err }
+
+func (e *OrderRuleError) Is(target error) bool { return target == ErrInvalidOrder }
+
+// PairSpec holds the trading rules of a pair. Indodax calls the quote
+// currency base_currency and the coin traded_currency; here Base is the coin
+// as in btc_idr.
+type PairSpec struct {
+	Pair  string
+	Base  string
+	Quote string
+	// PriceTick is the price increment; zero when unknown.
+	PriceTick decimal.Decimal
+	// AmountPlaces is the number of decimals of an amount of the base coin
+	// (volume_precision), PricePlaces that of a price or an amount of the
+	// quote currency (price_round).
+	AmountPlaces int32
+	PricePlaces  int32
+	// MinAmount is the smallest order in the base coin, MinValue the
+	// smallest in the quote currency.
+	MinAmount decimal.Decimal
+	MinValue  decimal.Decimal
+	Open      bool
+}
+
+func newPairSpec(p Pair, increments map[string]decimal.Decimal) PairSpec {
+	pair := strings.ToLower(p.TickerID)
+	if pair == "" {
+		pair = strings.ToLower(p.TradedCurrency + "_" + p.BaseCurrency)
+	}
+	base, quote, _ := strings.Cut(pair, "_")
+	tick, ok := increments[pair]
+	if !ok {
+		tick = p.PricePrecision
+	}
+	return PairSpec{
+		Pair:         pair,
+		Base:         base,
+		Quote:        quote,
+		PriceTick:    tick,
+		AmountPlaces: int32(p.VolumePrecision),
+		PricePlaces:  int32(p.PriceRound),
+		MinAmount:    p.TradeMinTradedCurrency,
+		MinValue:     p.TradeMinBaseCurrency,
+		Open:         p.Open(),
+	}
+}
+
+// RoundPrice puts price on the tick, or on the pair's decimals when the tick
+// is unknown, towards the passive side: down for buys and up for sells, so
+// the order never trades through the price asked for.
+func (s PairSpec) RoundPrice(price decimal.Decimal, side OrderSide) decimal.Decimal {
+	if !s.PriceTick.IsPositive() {
+		if side == Sell {
+			return price.RoundCeil(s.PricePlaces)
+		}
+		return price.RoundFloor(s.PricePlaces)
+	}
+	ticks := price.Div(s.PriceTick)
+	if side == Sell {
+		ticks = ticks.Ceil()
+	} else {
+		ticks = ticks.Floor()
+	}
+	return ticks.Mul(s.PriceTick)
+}
+
+// Round returns req with its price on the tick and its amounts truncated to
+// the pair's precision, then checks it with Check.
+func (s PairSpec) Round(req OrderRequest) (OrderRequest, error) {
+	if !req.Price.IsZero() {
+		req.Price = s.RoundPrice(req.Price, req.Side)
+	}
+	req.Amount = req.Amount.Truncate(s.AmountPlaces)
+	req.QuoteAmount = req.QuoteAmount.Truncate(s.PricePlaces)
+	return req, s.Check(req)
+}
+
+// Check returns an OrderRuleError when req is off the tick, more precise
+// than the pair allows or below its minimum size, or when the market is
+// closed.
+func (s PairSpec) Check(req OrderRequest) error {
+	ruleErr := func(field string, value, limit decimal.Decimal, err error) error {
+		return &OrderRuleError{Pair: s.Pair, Field: field, Value: value, Limit: limit, Err: err}
+	}
+	if !s.Open {
+		return &OrderRuleError{Pair: s.Pair, Err: ErrMarketClosed}
+	}
+	if s.PriceTick.IsPositive() && !req.Price.Mod(s.PriceTick).IsZero() {
+		return ruleErr("price", req.Price, s.PriceTick, ErrPriceTick)
+	}
+	if !req.Price.Equal(req.Price.Truncate(s.PricePlaces)) {
+		return ruleErr("price", req.Price, decimal.NewFromInt32(s.PricePlaces), ErrPricePlaces)
+	}
+	if !req.Amount.Equal(req.Amount.Truncate(s.AmountPlaces)) {
+		return ruleErr("amount", req.Amount, decimal.NewFromInt32(s.AmountPlaces), ErrAmountPlaces)
+	}
+	if !req.QuoteAmount.Equal(req.QuoteAmount.Truncate(s.PricePlaces)) {
+		return ruleErr("quote amount", req.QuoteAmount, decimal.NewFromInt32(s.PricePlaces), ErrAmountPlaces)
+	}
+	if !req.Amount.IsZero() && req.Amount.LessThan(s.MinAmount) {
+		return ruleErr("amount", req.Amount, s.MinAmount, ErrBelowMinimum)
+	}
+	// a market sell's value is only known once it fills
+	value := req.QuoteAmount
+	if value.IsZero() && !req.Price.IsZero() {
+		value = req.Amount.Mul(req.Price)
+	}
+	if !value.IsZero() && value.LessThan(s.MinValue) {
+		return ruleErr("value", value, s.MinValue, ErrBelowMinimum)
+	}
+	return nil
+}
+
+// DefaultPairTTL is how long PairCache keeps pair specs before reloading.
+var DefaultPairTTL = time.Hour
+
+// DefaultPairRetry is how long PairCache waits after a failed load before
+// trying again; until then Spec serves the specs already loaded, or the
+// error of the failed load when there are none.
+var DefaultPairRetry = 30 * time.Second
+
+// PairCache loads the pair specs and price increments of Indodax and
+// reloads them once they are older than the TTL. A failed reload keeps the
+// specs already loaded, since pair rules rarely change. Concurrent callers
+// share one load.
+type PairCache struct {
+	public *IndodaxPublicClient
+	ttl    time.Duration
+	retry  time.Duration
+
+	mu     sync.Mutex
+	specs  map[string]PairSpec
+	loaded time.Time
+	// inflight is the running load, nil when none is
+	inflight *pairLoad
+	failed   time.Time
+	err      error
+}
+
+// pairLoad is a load shared by every caller that asked for it while it ran.
+type pairLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// NewPairCache returns an empty cache; specs are loaded on first use. A zero
+// ttl means DefaultPairTTL.
+func NewPairCache(public *IndodaxPublicClient, ttl time.Duration) *PairCache {
+	if ttl <= 0 {
+		ttl = DefaultPairTTL
+	}
+	return &PairCache{public: public, ttl: ttl, retry: DefaultPairRetry}
+}
+
+// Refresh reloads every pair spec. A call made while another is loading
+// waits for that load and returns its result.
+func (c *PairCache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	if l := c.inflight; l != nil {
+		c.mu.Unlock()
+		select {
+		case <-l.done:
+			return l.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	l := &pairLoad{done: make(chan struct{})}
+	c.inflight = l
+	c.mu.Unlock()
+
+	specs, err := c.load(ctx)
+
+	c.mu.Lock()
+	c.inflight = nil
+	switch {
+	case err == nil:
+		c.specs = specs
+		c.loaded = time.Now()
+		c.err = nil
+	case ctx.Err() == nil:
+		// a caller giving up is not a failure of Indodax
+		c.failed = time.Now()
+		c.err = err
+	}
+	c.mu.Unlock()
+	l.err = err
+	close(l.done)
+	return err
+}
+
+func (c *PairCache) load(ctx context.Context) (map[string]PairSpec, error) {
+	pairs, err := c.public.Pairs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load pairs: %w", err)
+	}
+	increments, err := c.public.PriceIncrements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load price increments: %w", err)
+	}
+	specs := make(map[string]PairSpec, len(pairs))
+	for _, p := range pairs {
+		spec := newPairSpec(p, increments)
+		specs[pairID(spec.Pair)] = spec
+	}
+	return specs, nil
+}
+
+// Spec returns the rules of pair, given as btc_idr or btcidr. Stale specs
+// are reloaded, but no sooner than the retry delay after a failed load.
+func (c *PairCache) Spec(ctx context.Context, pair string) (PairSpec, error) {
+	c.mu.Lock()
+	stale := c.specs == nil || time.Since(c.loaded) > c.ttl
+	err := c.err
+	retry := err == nil || time.Since(c.failed) >= c.retry
+	c.mu.Unlock()
+	if stale {
+		if retry {
+			err = c.Refresh(ctx)
+		}
+		if err != nil {
+			c.mu.Lock()
+			empty := c.specs == nil
+			c.mu.Unlock()
+			if empty {
+				return PairSpec{}, err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spec, ok := c.specs[pairID(pair)]
+	if !ok {
+		return PairSpec{}, &OrderRuleError{Pair: pair, Err: ErrUnknownPair}
+	}
+	return spec, nil
+}
+
+// Round rounds req to the rules of its pair, see PairSpec.Round.
+func (c *PairCache) Round(ctx context.Context, req OrderRequest) (OrderRequest, error) {
+	spec, err := c.Spec(ctx, req.Pair)
+	if err != nil {
+		return req, err
+	}
+	return spec.Round(req)
+}
+
+// Check checks req against the rules of its pair, see PairSpec.Check.
+func (c *PairCache) Check(ctx context.Context, req OrderRequest) error {
+	spec, err := c.Spec(ctx, req.Pair)
+	if err != nil {
+		return err
+	}
+	return spec.Check(req)
+}
@@ -0,0 +1,315 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+// pairsJSON lists btc_idr with a 1000 IDR tick, eth_usdt with no tick
+// beyond its two price decimals, and doge_idr under maintenance.
+const pairsJSON = `[
+	{"ticker_id": "btc_idr", "base_currency": "idr", "traded_currency": "btc",
+	 "volume_precision": 8, "price_precision": 1000, "price_round": 0,
+	 "trade_min_base_currency": 10000, "trade_min_traded_currency": 0.0001},
+	{"ticker_id": "eth_usdt", "base_currency": "usdt", "traded_currency": "eth",
+	 "volume_precision": 4, "price_precision": 0, "price_round": 2,
+	 "trade_min_base_currency": "5", "trade_min_traded_currency": "0.001"},
+	{"ticker_id": "doge_idr", "base_currency": "idr", "traded_currency": "doge",
+	 "volume_precision": 0, "price_precision": 1, "price_round": 0,
+	 "trade_min_base_currency": 10000, "trade_min_traded_currency": 1,
+	 "is_maintenance": 1}
+]`
+
+const incrementsJSON = `{"increments": {"btc_idr": "1000"}}`
+
+// pairServer serves the pair list and price increments. Loads count the
+// /api/pairs requests; while failing is set they get a 500.
+type pairServer struct {
+	*httptest.Server
+	loads   atomic.Int32
+	failing atomic.Bool
+	// hold, when set, is waited on before answering /api/pairs
+	hold chan struct{}
+}
+
+func newPairServer(t *testing.T) *pairServer {
+	t.Helper()
+	s := &pairServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pairs":
+			s.loads.Add(1)
+			if s.hold != nil {
+				<-s.hold
+			}
+			if s.failing.Load() {
+				http.Error(w, `{"error": "unavailable"}`, http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(pairsJSON))
+		case "/api/price_increments":
+			w.Write([]byte(incrementsJSON))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pairServer) cache(ttl time.Duration) *client.PairCache {
+	return client.NewPairCache(client.NewPublicClient(s.URL, nil), ttl)
+}
+
+func TestPairRound(t *testing.T) {
+	c := newPairServer(t).cache(0)
+	tests := []struct {
+		name string
+		req  client.OrderRequest
+		want client.OrderRequest
+	}{
+		{
+			name: "buy price rounds down to the tick",
+			req:  client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("500000999"), Amount: dec("0.01")},
+			want: client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("500000000"), Amount: dec("0.01")},
+		},
+		{
+			name: "sell price rounds up to the tick",
+			req:  client.OrderRequest{Pair: "btc_idr", Side: client.Sell, Price: dec("500000001"), Amount: dec("0.01")},
+			want: client.OrderRequest{Pair: "btc_idr", Side: client.Sell, Price: dec("500001000"), Amount: dec("0.01")},
+		},
+		{
+			name: "buy price rounds down to price_round without a tick",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Price: dec("2500.129"), Amount: dec("0.01")},
+			want: client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Price: dec("2500.12"), Amount: dec("0.01")},
+		},
+		{
+			name: "sell price rounds up to price_round without a tick",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("2500.121"), Amount: dec("0.01")},
+			want: client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("2500.13"), Amount: dec("0.01")},
+		},
+		{
+			name: "amount truncates to volume_precision",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("2500"), Amount: dec("0.123456")},
+			want: client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("2500"), Amount: dec("0.1234")},
+		},
+		{
+			name: "quote amount truncates to price_round",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Type: client.OrderMarket, QuoteAmount: dec("10.999")},
+			want: client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Type: client.OrderMarket, QuoteAmount: dec("10.99")},
+		},
+		{
+			name: "pair given without an underscore",
+			req:  client.OrderRequest{Pair: "btcidr", Side: client.Buy, Price: dec("500000500"), Amount: dec("0.01")},
+			want: client.OrderRequest{Pair: "btcidr", Side: client.Buy, Price: dec("500000000"), Amount: dec("0.01")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Round(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Price.Equal(tt.want.Price) || !got.Amount.Equal(tt.want.Amount) || !got.QuoteAmount.Equal(tt.want.QuoteAmount) {
+				t.Errorf("Round = price %s amount %s quote %s, want price %s amount %s quote %s",
+					got.Price, got.Amount, got.QuoteAmount, tt.want.Price, tt.want.Amount, tt.want.QuoteAmount)
+			}
+		})
+	}
+}
+
+func TestPairCheck(t *testing.T) {
+	c := newPairServer(t).cache(0)
+	tests := []struct {
+		name  string
+		req   client.OrderRequest
+		want  error
+		field string
+	}{
+		{
+			name: "on the rules",
+			req:  client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("500000000"), Amount: dec("0.0001")},
+		},
+		{
+			name:  "off the tick",
+			req:   client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("500000500"), Amount: dec("0.01")},
+			want:  client.ErrPriceTick,
+			field: "price",
+		},
+		{
+			name:  "too many price decimals",
+			req:   client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Price: dec("2500.001"), Amount: dec("0.01")},
+			want:  client.ErrPricePlaces,
+			field: "price",
+		},
+		{
+			name:  "too many amount decimals",
+			req:   client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Price: dec("2500"), Amount: dec("0.01001")},
+			want:  client.ErrAmountPlaces,
+			field: "amount",
+		},
+		{
+			name:  "too many quote amount decimals",
+			req:   client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Type: client.OrderMarket, QuoteAmount: dec("10.001")},
+			want:  client.ErrAmountPlaces,
+			field: "quote amount",
+		},
+		{
+			name: "amount at MinAmount",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("5000"), Amount: dec("0.001")},
+		},
+		{
+			name:  "amount below MinAmount",
+			req:   client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Price: dec("50000"), Amount: dec("0.0009")},
+			want:  client.ErrBelowMinimum,
+			field: "amount",
+		},
+		{
+			name: "value at MinValue",
+			req:  client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("100000000"), Amount: dec("0.0001")},
+		},
+		{
+			name:  "value below MinValue",
+			req:   client.OrderRequest{Pair: "btc_idr", Side: client.Buy, Price: dec("99000000"), Amount: dec("0.0001")},
+			want:  client.ErrBelowMinimum,
+			field: "value",
+		},
+		{
+			name: "quote amount at MinValue",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Type: client.OrderMarket, QuoteAmount: dec("5")},
+		},
+		{
+			name:  "quote amount below MinValue",
+			req:   client.OrderRequest{Pair: "eth_usdt", Side: client.Buy, Type: client.OrderMarket, QuoteAmount: dec("4.99")},
+			want:  client.ErrBelowMinimum,
+			field: "value",
+		},
+		{
+			name: "market sell has no value to check",
+			req:  client.OrderRequest{Pair: "eth_usdt", Side: client.Sell, Type: client.OrderMarket, Amount: dec("0.001")},
+		},
+		{
+			name: "unknown pair",
+			req:  client.OrderRequest{Pair: "xyz_idr", Side: client.Buy, Price: dec("1"), Amount: dec("1")},
+			want: client.ErrUnknownPair,
+		},
+		{
+			name: "closed market",
+			req:  client.OrderRequest{Pair: "doge_idr", Side: client.Buy, Price: dec("2000"), Amount: dec("10")},
+			want: client.ErrMarketClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Check(context.Background(), tt.req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			var ruleErr *client.OrderRuleError
+			if !errors.Is(err, tt.want) || !errors.Is(err, client.ErrInvalidOrder) || !errors.As(err, &ruleErr) {
+				t.Fatalf("err = %v, want an OrderRuleError for %v", err, tt.want)
+			}
+			if ruleErr.Field != tt.field {
+				t.Errorf("field = %q, want %q", ruleErr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestPairCacheSharesLoad(t *testing.T) {
+	srv := newPairServer(t)
+	srv.hold = make(chan struct{})
+	c := srv.cache(0)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Spec(context.Background(), "btc_idr")
+			errs <- err
+		}()
+	}
+	// let every caller reach the cache before the load answers
+	time.Sleep(50 * time.Millisecond)
+	close(srv.hold)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := srv.loads.Load(); n != 1 {
+		t.Errorf("%d loads, want 1 shared by %d callers", n, callers)
+	}
+}
+
+func TestPairCacheRetryAfterFailedReload(t *testing.T) {
+	saved := client.DefaultPairRetry
+	t.Cleanup(func() { client.DefaultPairRetry = saved })
+	client.DefaultPairRetry = 100 * time.Millisecond
+
+	srv := newPairServer(t)
+	// every call finds the specs stale
+	c := srv.cache(time.Nanosecond)
+	ctx := context.Background()
+	if _, err := c.Spec(ctx, "btc_idr"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.failing.Store(true)
+	for i := 0; i < 3; i++ {
+		// the failed reload keeps the specs already loaded
+		if _, err := c.Spec(ctx, "btc_idr"); err != nil {
+			t.Fatalf("call %d after a failed reload: %v", i, err)
+		}
+	}
+	if n := srv.loads.Load(); n != 2 {
+		t.Errorf("%d loads within the retry delay, want 2", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	srv.failing.Store(false)
+	if _, err := c.Spec(ctx, "btc_idr"); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.loads.Load(); n != 3 {
+		t.Errorf("%d loads after the retry delay, want 3", n)
+	}
+}
+
+func TestPairCacheFailedFirstLoad(t *testing.T) {
+	saved := client.DefaultPairRetry
+	t.Cleanup(func() { client.DefaultPairRetry = saved })
+	client.DefaultPairRetry = time.Hour
+
+	srv := newPairServer(t)
+	srv.failing.Store(true)
+	c := srv.cache(0)
+	ctx := context.Background()
+	_, first := c.Spec(ctx, "btc_idr")
+	if first == nil {
+		t.Fatal("Spec with no specs loaded succeeded")
+	}
+	// within the retry delay the error is served without asking again
+	if _, err := c.Spec(ctx, "btc_idr"); err == nil || err.Error() != first.Error() {
+		t.Errorf("second call: err = %v, want %v", err, first)
+	}
+	if n := srv.loads.Load(); n != 1 {
+		t.Errorf("%d loads, want 1", n)
+	}
+}
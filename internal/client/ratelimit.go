@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Indodax allows 20 trade requests per second per account and pair and 30
+// cancel requests per second per account, and blocks the account and pair
+// for 5 seconds once a limit is exceeded.
+var defaultRates = map[string]float64{
+	"trade":  20,
+	"cancel": 30,
+}
+
+// accountLimits maps the methods limited per account rather than per pair
+// to the bucket they share: both ways of cancelling count against one limit.
+var accountLimits = map[string]string{
+	"cancelOrder":           "cancel",
+	"cancelByClientOrderId": "cancel",
+}
+
+const defaultBlock = 5 * time.Second
+
+// RateLimitMode decides what a call does when its limit is used up.
+type RateLimitMode int
+
+const (
+	// RateLimitWait delays the call until it is allowed, or until the
+	// context is done.
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast returns ErrTooManyRequests straight away.
+	RateLimitFailFast
+)
+
+// limitKey names a bucket: a method and pair, or a group of methods with an
+// account-wide limit and no pair.
+type limitKey struct {
+	method string
+	pair   string
+}
+
+func keyFor(method, pair string) limitKey {
+	if group, ok := accountLimits[method]; ok {
+		return limitKey{method: group}
+	}
+	return limitKey{method, pair}
+}
+
+type bucket struct {
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// Limiter is a token bucket per method and pair, or per account for the
+// cancel methods. Methods without a rate are not throttled but still
+// respect a block recorded after a 429. A Limiter may be shared by clients
+// using the same account.
+type Limiter struct {
+	mu      sync.Mutex
+	rates   map[string]float64
+	block   time.Duration
+	buckets map[limitKey]*bucket
+}
+
+// NewLimiter returns a limiter with the documented Indodax limits.
+func NewLimiter() *Limiter {
+	rates := make(map[string]float64, len(defaultRates))
+	for m, r := range defaultRates {
+		rates[m] = r
+	}
+	return &Limiter{
+		rates:   rates,
+		block:   defaultBlock,
+		buckets: make(map[limitKey]*bucket),
+	}
+}
+
+// SetRate changes the requests per second allowed for method, or for every
+// method sharing its account-wide limit; zero removes the limit.
+func (l *Limiter) SetRate(method string, perSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := keyFor(method, "")
+	if perSecond <= 0 {
+		delete(l.rates, k.method)
+		return
+	}
+	l.rates[k.method] = perSecond
+}
+
+// SetBlock changes how long a method and pair stay blocked after a 429.
+func (l *Limiter) SetBlock(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.block = d
+}
+
+func (l *Limiter) bucket(k limitKey, now time.Time) *bucket {
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{tokens: l.rates[k.method], last: now}
+		l.buckets[k] = b
+	}
+	return b
+}
+
+// reserve takes a token for method and pair if one is available and
+// otherwise returns how long to wait before trying again.
+func (l *Limiter) reserve(method, pair string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	k := keyFor(method, pair)
+	b := l.bucket(k, now)
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+	rate, ok := l.rates[k.method]
+	if !ok {
+		return 0
+	}
+	// the bucket holds at most one second of requests
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > rate {
+		b.tokens = rate
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Block stops calls for method and pair for the server's block window, as
+// after a 429 reply.
+func (l *Limiter) Block(method, pair string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	b := l.bucket(keyFor(method, pair), now)
+	b.blockedUntil = now.Add(l.block)
+	b.tokens = 0
+}
+
+// Allow takes a token without waiting. When none is available it returns
+// false and the time until the next one.
+func (l *Limiter) Allow(method, pair string) (bool, time.Duration) {
+	wait := l.reserve(method, pair)
+	return wait == 0, wait
+}
+
+// Wait blocks until a call for method and pair is allowed.
+func (l *Limiter) Wait(ctx context.Context, method, pair string) error {
+	for {
+		wait := l.reserve(method, pair)
+		if wait == 0 {
+			return nil
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// acquire applies mode to a call for method and pair.
+func (l *Limiter) acquire(ctx context.Context, mode RateLimitMode, method, pair string) error {
+	if mode == RateLimitWait {
+		return l.Wait(ctx, method, pair)
+	}
+	if ok, wait := l.Allow(method, pair); !ok {
+		return fmt.Errorf("%w: %s %s, retry in %s", ErrTooManyRequests, method, pair, wait.Round(time.Millisecond))
+	}
+	return nil
+}
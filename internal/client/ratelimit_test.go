@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+func TestLimiterSetBlock(t *testing.T) {
+	l := client.NewLimiter()
+	l.SetBlock(50 * time.Millisecond)
+	l.Block("trade", "btc_idr")
+
+	ok, wait := l.Allow("trade", "btc_idr")
+	if ok || wait <= 0 || wait > 50*time.Millisecond {
+		t.Fatalf("Allow during block = %v, %s; want false within 50ms", ok, wait)
+	}
+	if ok, _ := l.Allow("trade", "eth_idr"); !ok {
+		t.Error("block on btc_idr held back eth_idr")
+	}
+	time.Sleep(wait + 10*time.Millisecond)
+	if ok, wait := l.Allow("trade", "btc_idr"); !ok {
+		t.Errorf("Allow after block = false, retry in %s", wait)
+	}
+}
+
+func TestLimiterBuckets(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  string
+		per   float64
+		calls [][2]string // method, pair
+		// allowed is whether each call gets a token
+		allowed []bool
+	}{
+		{
+			name: "trade is limited per pair",
+			rate: "trade",
+			per:  1,
+			calls: [][2]string{
+				{"trade", "btc_idr"}, {"trade", "eth_idr"}, {"trade", "btc_idr"},
+			},
+			allowed: []bool{true, true, false},
+		},
+		{
+			name: "cancels share one account-wide bucket",
+			rate: "cancelOrder",
+			per:  2,
+			calls: [][2]string{
+				{"cancelOrder", "btc_idr"}, {"cancelByClientOrderId", ""}, {"cancelOrder", "eth_idr"},
+			},
+			allowed: []bool{true, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := client.NewLimiter()
+			// a low rate so no token comes back while the test runs
+			l.SetRate(tt.rate, tt.per)
+			for i, c := range tt.calls {
+				if ok, _ := l.Allow(c[0], c[1]); ok != tt.allowed[i] {
+					t.Errorf("call %d %s %s: allowed = %v, want %v", i, c[0], c[1], ok, tt.allowed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterBlocksCancelsAccountWide(t *testing.T) {
+	l := client.NewLimiter()
+	l.Block("cancelOrder", "btc_idr")
+	if ok, _ := l.Allow("cancelByClientOrderId", ""); ok {
+		t.Error("cancel by client order id allowed while cancels are blocked")
+	}
+	if ok, _ := l.Allow("trade", "btc_idr"); !ok {
+		t.Error("trade held back by a cancel block")
+	}
+}
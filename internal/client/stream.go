@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+)
+
+const binanceStreamURL = "wss://stream.binance.com:9443/ws/"
+
+// Binance drops every connection after 24 hours; reconnecting a little
+// earlier avoids losing a candle close to the forced disconnect.
+const streamMaxAge = 23*time.Hour + 50*time.Minute
+
+// KlineStream subscribes to the Binance <symbol>@kline_<interval> stream and
+// delivers each candle once it has closed.
+type KlineStream struct {
+	Symbol   string
+	Interval string
+	// URL is the stream endpoint; the stream name is appended to it.
+	URL    string
+	Dialer *websocket.Dialer
+	// REST fetches candles missed while reconnecting.
+	REST *BinanceClient
+	// ReadTimeout closes a connection that has been silent, including
+	// pings, for this long.
+	ReadTimeout time.Duration
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+func NewKlineStream(rest *BinanceClient, symbol, interval string) *KlineStream {
+	return &KlineStream{
+		Symbol:      strings.ToUpper(symbol),
+		Interval:    interval,
+		URL:         binanceStreamURL,
+		Dialer:      websocket.DefaultDialer,
+		REST:        rest,
+		ReadTimeout: time.Minute,
+		MinBackoff:  time.Second,
+		MaxBackoff:  2 * time.Minute,
+	}
+}
+
+type klineEvent struct {
+	Symbol string `json:"s"`
+	Kline  struct {
+		OpenTime            int64            `json:"t"`
+		CloseTime           int64            `json:"T"`
+		Open                *decimal.Decimal `json:"o"`
+		High                *decimal.Decimal `json:"h"`
+		Low                 *decimal.Decimal `json:"l"`
+		Close               *decimal.Decimal `json:"c"`
+		Volume              *decimal.Decimal `json:"v"`
+		QuoteVolume         *decimal.Decimal `json:"q"`
+		Trades              int64            `json:"n"`
+		TakerBuyBaseVolume  *decimal.Decimal `json:"V"`
+		TakerBuyQuoteVolume *decimal.Decimal `json:"Q"`
+		Closed              bool             `json:"x"`
+	} `json:"k"`
+}
+
+// kline converts the event payload, rejecting candles with missing prices
+// rather than letting them through as zero.
+func (ev klineEvent) kline() (Kline, error) {
+	e := ev.Kline
+	for name, d := range map[string]*decimal.Decimal{
+		"open": e.Open, "high": e.High, "low": e.Low, "close": e.Close, "volume": e.Volume,
+	} {
+		if d == nil {
+			return Kline{}, fmt.Errorf("kline event %d: missing %s", e.OpenTime, name)
+		}
+	}
+	k := Kline{
+		OpenTime:  time.UnixMilli(e.OpenTime).UTC(),
+		CloseTime: time.UnixMilli(e.CloseTime).UTC(),
+		Open:      *e.Open,
+		High:      *e.High,
+		Low:       *e.Low,
+		Close:     *e.Close,
+		Volume:    *e.Volume,
+		Trades:    e.Trades,
+	}
+	if e.QuoteVolume != nil {
+		k.QuoteVolume = *e.QuoteVolume
+	}
+	if e.TakerBuyBaseVolume != nil {
+		k.TakerBuyBaseVolume = *e.TakerBuyBaseVolume
+	}
+	if e.TakerBuyQuoteVolume != nil {
+		k.TakerBuyQuoteVolume = *e.TakerBuyQuoteVolume
+	}
+	return k, nil
+}
+
+// Subscribe connects in the background and returns a channel of closed
+// candles, oldest first. Dropped connections are redialled with exponential
+// backoff, and candles that closed while disconnected are fetched over REST
+// so none are skipped. The channel is closed once ctx is cancelled.
+func (s *KlineStream) Subscribe(ctx context.Context) (<-chan Kline, error) {
+	if _, err := IntervalDuration(s.Interval); err != nil {
+		return nil, err
+	}
+	out := make(chan Kline)
+	go s.run(ctx, out)
+	return out, nil
+}
+
+func (s *KlineStream) run(ctx context.Context, out chan<- Kline) {
+	defer close(out)
+	var last time.Time
+	backoff := s.MinBackoff
+	for ctx.Err() == nil {
+		connected, err := s.consume(ctx, out, &last)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = s.MinBackoff
+		}
+		log.Printf("kline stream %s %s: %v, reconnecting in %s", s.Symbol, s.Interval, err, backoff)
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+	}
+}
+
+// consume reads one connection until it fails or reaches streamMaxAge. It
+// reports whether the connection got as far as streaming, so the caller can
+// reset its backoff.
+func (s *KlineStream) consume(ctx context.Context, out chan<- Kline, last *time.Time) (bool, error) {
+	name := strings.ToLower(s.Symbol) + "@kline_" + s.Interval
+	conn, _, err := s.Dialer.DialContext(ctx, s.URL+name, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// unblock ReadMessage on shutdown or when the connection gets too old
+	expire := time.AfterFunc(streamMaxAge, func() { conn.Close() })
+	defer expire.Stop()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+
+	if err := s.catchUp(ctx, out, last); err != nil {
+		return false, err
+	}
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		var ev klineEvent
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			return true, fmt.Errorf("decode kline event: %w", err)
+		}
+		if !ev.Kline.Closed {
+			continue
+		}
+		k, err := ev.kline()
+		if err != nil {
+			return true, err
+		}
+		if !k.OpenTime.After(*last) {
+			continue
+		}
+		if !s.emit(ctx, out, k, last) {
+			return true, ctx.Err()
+		}
+	}
+}
+
+// catchUp emits candles that closed after the last one delivered, covering
+// the time spent reconnecting. It does nothing before the first candle.
+func (s *KlineStream) catchUp(ctx context.Context, out chan<- Kline, last *time.Time) error {
+	if last.IsZero() {
+		return nil
+	}
+	dur, _ := IntervalDuration(s.Interval)
+	now := time.Now()
+	klines, _, err := s.REST.KlinesBetween(ctx, s.Symbol, s.Interval, last.Add(dur), now)
+	if err != nil {
+		return err
+	}
+	for _, k := range klines {
+		if k.OpenTime.Add(dur).After(now) {
+			break
+		}
+		if !s.emit(ctx, out, k, last) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s *KlineStream) emit(ctx context.Context, out chan<- Kline, k Kline, last *time.Time) bool {
+	select {
+	case out <- k:
+		*last = k.OpenTime
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,32 +1,215 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// Flag is a boolean Indodax sends as 1/0, "1"/"0" or true/false.
+type Flag bool
+
+func (f *Flag) UnmarshalJSON(b []byte) error {
+	switch strings.Trim(string(b), `"`) {
+	case "1", "true":
+		*f = true
+	case "0", "false", "", "null":
+		*f = false
+	default:
+		return fmt.Errorf("flag: unexpected %s", b)
+	}
+	return nil
+}
+
+// StringList is a list Indodax sends as a plain string when it has a single
+// element, as in getInfo's networks.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var one string
+	if json.Unmarshal(b, &one) == nil {
+		*l = StringList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("string list: %w", err)
+	}
+	*l = many
+	return nil
+}
+
 // Base response structure for all API calls
 type BaseResponse struct {
-	Success int    `json:"success"`
-	Error   string `json:"error,omitempty"`
+	Success   Flag   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// field is a key of a reply decoded by decodeFields.
+type field struct {
+	key string
+	dst interface{}
+}
+
+// decodeFields unmarshals the keys of raw that are present into their
+// destinations. Numbers and numeric strings are accepted alike, and empty
+// strings and nulls leave the destination at its zero value.
+func decodeFields(raw map[string]json.RawMessage, fields []field) error {
+	for _, f := range fields {
+		v, ok := raw[f.key]
+		if !ok || string(v) == "null" || string(v) == `""` {
+			continue
+		}
+		var err error
+		switch dst := f.dst.(type) {
+		case *int64:
+			*dst, err = strconv.ParseInt(strings.Trim(string(v), `"`), 10, 64)
+		case *string:
+			if v[0] != '"' {
+				*dst = string(v)
+			} else {
+				err = json.Unmarshal(v, dst)
+			}
+		default:
+			err = json.Unmarshal(v, dst)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+	}
+	return nil
+}
+
+// coinSide reports whether coin is the quote rather than the base currency
+// of pair, and false for ok when it is neither. "rp" is Indodax's name for
+// rupiah. Without a pair, IDR counts as the quote and any other coin as the
+// base.
+func coinSide(pair, coin string) (quote, ok bool) {
+	if coin == "rp" {
+		return true, true
+	}
+	if pair == "" {
+		return coin == "idr", true
+	}
+	if base, q, ok := strings.Cut(strings.ToLower(pair), "_"); ok {
+		switch coin {
+		case base:
+			return false, true
+		case q:
+			return true, true
+		}
+		return false, false
+	}
+	// btcidr: the coin the pair starts with is the base, the one it ends
+	// with the quote
+	id := pairID(pair)
+	switch {
+	case strings.HasPrefix(id, coin):
+		return false, true
+	case strings.HasSuffix(id, coin):
+		return true, true
+	}
+	return false, false
+}
+
+// coinAmounts holds the coin-named amounts of a reply (order_btc,
+// remain_idr, receive_eth, ...) keyed as sent.
+type coinAmounts map[string]decimal.Decimal
+
+// amountPrefixes are the coin-named amount fields of orders. Trade replies
+// say spend_ and sold_ for what order replies call spent_.
+var amountPrefixes = []string{"order_", "remain_", "receive_", "spent_", "spend_", "sold_", "refund_"}
+
+func collectCoins(raw map[string]json.RawMessage) (coinAmounts, error) {
+	coins := coinAmounts{}
+	for key := range raw {
+		if key == "order_id" || key == "order_type" {
+			continue
+		}
+		for _, p := range amountPrefixes {
+			if !strings.HasPrefix(key, p) {
+				continue
+			}
+			var d decimal.Decimal
+			if err := decodeFields(raw, []field{{key, &d}}); err != nil {
+				return nil, err
+			}
+			coins[key] = d
+			break
+		}
+	}
+	return coins, nil
+}
+
+// OrderAmounts are the coin-named amounts of an order mapped to the base and
+// quote currency of its pair. Orders are sized either in the base coin
+// (OrderBase) or, for buys, in the quote currency (OrderQuote).
+type OrderAmounts struct {
+	OrderBase    decimal.Decimal `json:"order_base"`
+	OrderQuote   decimal.Decimal `json:"order_quote"`
+	RemainBase   decimal.Decimal `json:"remain_base"`
+	RemainQuote  decimal.Decimal `json:"remain_quote"`
+	ReceiveBase  decimal.Decimal `json:"receive_base"`
+	ReceiveQuote decimal.Decimal `json:"receive_quote"`
+	SpentBase    decimal.Decimal `json:"spent_base"`
+	SpentQuote   decimal.Decimal `json:"spent_quote"`
+	RefundBase   decimal.Decimal `json:"refund_base"`
+	RefundQuote  decimal.Decimal `json:"refund_quote"`
+}
+
+func (a *OrderAmounts) resolve(pair string, coins coinAmounts) {
+	*a = OrderAmounts{}
+	for key, v := range coins {
+		prefix, coin, _ := strings.Cut(key, "_")
+		quote, ok := coinSide(pair, coin)
+		if !ok {
+			continue
+		}
+		var base, q *decimal.Decimal
+		switch prefix {
+		case "order":
+			base, q = &a.OrderBase, &a.OrderQuote
+		case "remain":
+			base, q = &a.RemainBase, &a.RemainQuote
+		case "receive":
+			base, q = &a.ReceiveBase, &a.ReceiveQuote
+		case "spent", "spend", "sold":
+			base, q = &a.SpentBase, &a.SpentQuote
+		case "refund":
+			base, q = &a.RefundBase, &a.RefundQuote
+		default:
+			continue
+		}
+		if quote {
+			*q = v
+		} else {
+			*base = v
+		}
+	}
 }
 
 // GetInfoResponse represents the response from getInfo endpoint
 type GetInfoResponse struct {
 	BaseResponse
 	Return struct {
-		ServerTime         int64                  `json:"server_time"`
-		Balance            map[string]string      `json:"balance"`
-		BalanceHold        map[string]string      `json:"balance_hold"`
-		Address            map[string]string      `json:"address"`
-		Network            map[string]interface{} `json:"network"`
-		MemoIsRequired     map[string]interface{} `json:"memo_is_required"`
-		UserID             string                 `json:"user_id"`
-		Name               string                 `json:"name"`
-		Email              string                 `json:"email"`
-		ProfilePicture     interface{}            `json:"profile_picture"`
-		VerificationStatus string                 `json:"verification_status"`
-		GauthEnable        bool                   `json:"gauth_enable"`
-		WithdrawStatus     int                    `json:"withdraw_status"`
+		ServerTime         UnixSeconds                `json:"server_time"`
+		Balance            map[string]decimal.Decimal `json:"balance"`
+		BalanceHold        map[string]decimal.Decimal `json:"balance_hold"`
+		Address            map[string]string          `json:"address"`
+		Network            map[string]StringList      `json:"network"`
+		MemoIsRequired     map[string]map[string]bool `json:"memo_is_required"`
+		UserID             string                     `json:"user_id"`
+		Name               string                     `json:"name"`
+		Email              string                     `json:"email"`
+		ProfilePicture     *string                    `json:"profile_picture"`
+		VerificationStatus string                     `json:"verification_status"`
+		GauthEnable        bool                       `json:"gauth_enable"`
+		WithdrawStatus     int                        `json:"withdraw_status"`
 	} `json:"return"`
 }
 
@@ -34,40 +217,123 @@ type GetInfoResponse struct {
 type TransHistoryResponse struct {
 	BaseResponse
 	Return struct {
-		Withdraw map[string][]Transaction `json:"withdraw"`
-		Deposit  map[string][]Transaction `json:"deposit"`
+		Withdraw Transfers `json:"withdraw"`
+		Deposit  Transfers `json:"deposit"`
 	} `json:"return"`
 }
 
-// Transaction represents a single transaction
+// Transfers are the deposits or withdrawals of transHistory keyed by
+// currency.
+type Transfers map[string][]Transaction
+
+func (t *Transfers) UnmarshalJSON(b []byte) error {
+	// nothing at all comes back as an empty list
+	if strings.TrimSpace(string(b)) == "[]" {
+		*t = Transfers{}
+		return nil
+	}
+	var raw map[string][]map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("transfers: %w", err)
+	}
+	out := make(Transfers, len(raw))
+	for currency, items := range raw {
+		list := make([]Transaction, 0, len(items))
+		for _, item := range items {
+			tx, err := parseTransaction(currency, item)
+			if err != nil {
+				return err
+			}
+			list = append(list, tx)
+		}
+		out[currency] = list
+	}
+	*t = out
+	return nil
+}
+
+// Transaction represents a single deposit or withdrawal. Indodax names the
+// gross amount after the currency (rp for IDR, btc for bitcoin, ...); it is
+// Gross here, and Amount is what remains after Fee.
 type Transaction struct {
-	Status      string `json:"status"`
-	Type        string `json:"type"`
-	RP          string `json:"rp,omitempty"`
-	Fee         string `json:"fee,omitempty"`
-	Amount      string `json:"amount,omitempty"`
-	SubmitTime  string `json:"submit_time"`
-	SuccessTime string `json:"success_time"`
-	ID          string `json:"withdraw_id,omitempty"`
-	TX          string `json:"tx"`
-	BTC         string `json:"btc,omitempty"`
+	Currency    string          `json:"currency"`
+	Status      string          `json:"status"`
+	Type        string          `json:"type,omitempty"`
+	Gross       decimal.Decimal `json:"gross"`
+	Fee         decimal.Decimal `json:"fee"`
+	Amount      decimal.Decimal `json:"amount"`
+	SubmitTime  UnixSeconds     `json:"submit_time"`
+	SuccessTime UnixSeconds     `json:"success_time"`
+	// ID is the withdraw_id or deposit_id.
+	ID string `json:"id"`
+	TX string `json:"tx"`
+}
+
+func parseTransaction(currency string, raw map[string]json.RawMessage) (Transaction, error) {
+	t := Transaction{Currency: currency}
+	gross := currency
+	if currency == "idr" {
+		gross = "rp"
+	}
+	err := decodeFields(raw, []field{
+		{"status", &t.Status},
+		{"type", &t.Type},
+		{gross, &t.Gross},
+		{"fee", &t.Fee},
+		{"amount", &t.Amount},
+		{"submit_time", &t.SubmitTime},
+		{"success_time", &t.SuccessTime},
+		{"withdraw_id", &t.ID},
+		{"deposit_id", &t.ID},
+		{"tx", &t.TX},
+	})
+	if err != nil {
+		return Transaction{}, fmt.Errorf("%s transaction: %w", currency, err)
+	}
+	return t, nil
 }
 
 // TradeResponse represents the response from trade endpoint
 type TradeResponse struct {
 	BaseResponse
-	Return struct {
-		OrderID       string `json:"order_id"`
-		ClientOrderID string `json:"client_order_id,omitempty"`
-		ReceiveIDR    string `json:"receive_idr,omitempty"`
-		ReceiveBTC    string `json:"receive_btc,omitempty"`
-		SpentIDR      string `json:"spent_idr,omitempty"`
-		SpentBTC      string `json:"spent_btc,omitempty"`
-		Fee           string `json:"fee,omitempty"`
-		SubmitTime    string `json:"submit_time"`
-		FinishTime    string `json:"finish_time,omitempty"`
-		Status        string `json:"status"`
-	} `json:"return"`
+	Return TradeResult `json:"return"`
+}
+
+// TradeResult is a placed order and what it filled immediately: a buy
+// reports ReceiveBase, SpentQuote and RemainQuote, a sell ReceiveQuote,
+// SpentBase and RemainBase.
+type TradeResult struct {
+	OrderID       int64           `json:"order_id"`
+	ClientOrderID string          `json:"client_order_id,omitempty"`
+	Pair          string          `json:"pair,omitempty"`
+	Fee           decimal.Decimal `json:"fee"`
+	OrderAmounts
+	coins coinAmounts
+}
+
+func (r *TradeResult) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("trade: %w", err)
+	}
+	err := decodeFields(raw, []field{
+		{"order_id", &r.OrderID},
+		{"client_order_id", &r.ClientOrderID},
+		{"fee", &r.Fee},
+	})
+	if err != nil {
+		return fmt.Errorf("trade: %w", err)
+	}
+	if r.coins, err = collectCoins(raw); err != nil {
+		return fmt.Errorf("trade: %w", err)
+	}
+	r.setPair("")
+	return nil
+}
+
+func (r *TradeResult) setPair(pair string) {
+	r.Pair = pair
+	r.resolve(pair, r.coins)
 }
 
 // TradeHistoryOption is a function type for trade history options
@@ -102,175 +368,311 @@ type TradeHistoryResponse struct {
 	} `json:"return"`
 }
 
-// TradeHistoryItem represents a single trade history item
+// TradeHistoryItem represents a single fill. Indodax names the filled
+// amount after the base coin ("btc": "0.003"); it is Amount here.
 type TradeHistoryItem struct {
-	OrderID       string `json:"order_id"`
-	ClientOrderID string `json:"client_order_id,omitempty"`
-	Type          string `json:"type"`
-	Price         string `json:"price"`
-	SubmitTime    string `json:"submit_time"`
-	FinishTime    string `json:"finish_time"`
-	Status        string `json:"status"`
-	OrderBTC      string `json:"order_btc,omitempty"`
-	RemainBTC     string `json:"remain_btc,omitempty"`
-	OrderIDR      string `json:"order_idr,omitempty"`
-	RemainIDR     string `json:"remain_idr,omitempty"`
-	ReceiveIDR    string `json:"receive_idr,omitempty"`
-	ReceiveBTC    string `json:"receive_btc,omitempty"`
-	SpentIDR      string `json:"spent_idr,omitempty"`
-	SpentBTC      string `json:"spent_btc,omitempty"`
-	Fee           string `json:"fee,omitempty"`
-}
-
-// OpenOrdersResponse represents the response from openOrders endpoint
+	TradeID       int64           `json:"trade_id"`
+	OrderID       int64           `json:"order_id"`
+	ClientOrderID string          `json:"client_order_id,omitempty"`
+	Pair          string          `json:"pair"`
+	Type          string          `json:"type"`
+	Price         decimal.Decimal `json:"price"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	TradeTime     UnixSeconds     `json:"trade_time"`
+	raw           map[string]json.RawMessage
+}
+
+func (t *TradeHistoryItem) fields() []field {
+	return []field{
+		{"trade_id", &t.TradeID},
+		{"order_id", &t.OrderID},
+		{"client_order_id", &t.ClientOrderID},
+		{"type", &t.Type},
+		{"price", &t.Price},
+		{"fee", &t.Fee},
+		{"trade_time", &t.TradeTime},
+	}
+}
+
+func (t *TradeHistoryItem) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &t.raw); err != nil {
+		return fmt.Errorf("trade history: %w", err)
+	}
+	if err := decodeFields(t.raw, t.fields()); err != nil {
+		return fmt.Errorf("trade history %d: %w", t.TradeID, err)
+	}
+	return nil
+}
+
+func (t *TradeHistoryItem) setPair(pair string) error {
+	t.Pair = pair
+	base, _, ok := strings.Cut(strings.ToLower(pair), "_")
+	if !ok {
+		base = t.baseKey(pair)
+	}
+	if err := decodeFields(t.raw, []field{{base, &t.Amount}}); err != nil {
+		return fmt.Errorf("trade history %d: %w", t.TradeID, err)
+	}
+	return nil
+}
+
+// baseKey finds the field named after the base coin of a pair given as
+// btcidr: the longest one, other than the fields every fill has, that the
+// pair starts with.
+func (t *TradeHistoryItem) baseKey(pair string) string {
+	known := map[string]bool{"pair": true}
+	for _, f := range t.fields() {
+		known[f.key] = true
+	}
+	var base string
+	for key := range t.raw {
+		if known[key] || len(key) <= len(base) {
+			continue
+		}
+		if quote, ok := coinSide(pair, key); ok && !quote {
+			base = key
+		}
+	}
+	return base
+}
+
+// OpenOrdersResponse represents the response from openOrders endpoint. For
+// every pair at once Indodax groups the orders by pair; they are flattened
+// here with Pair set.
 type OpenOrdersResponse struct {
 	BaseResponse
 	Return struct {
-		Orders []OpenOrder `json:"orders"`
+		Orders OrderList `json:"orders"`
 	} `json:"return"`
 }
 
-// OpenOrder represents a single open order
-type OpenOrder struct {
-	OrderID       string `json:"order_id"`
-	ClientOrderID string `json:"client_order_id,omitempty"`
-	SubmitTime    string `json:"submit_time"`
-	Price         string `json:"price"`
-	Type          string `json:"type"`
-	OrderType     string `json:"order_type"`
-	OrderBTC      string `json:"order_btc,omitempty"`
-	RemainBTC     string `json:"remain_btc,omitempty"`
-	OrderIDR      string `json:"order_idr,omitempty"`
-	RemainIDR     string `json:"remain_idr,omitempty"`
+// OrderList decodes either a list of orders or orders grouped by pair.
+type OrderList []Order
+
+func (l *OrderList) UnmarshalJSON(b []byte) error {
+	var list []Order
+	if json.Unmarshal(b, &list) == nil {
+		*l = list
+		return nil
+	}
+	var byPair map[string][]Order
+	if err := json.Unmarshal(b, &byPair); err != nil {
+		return fmt.Errorf("orders: %w", err)
+	}
+	out := OrderList{}
+	for pair, orders := range byPair {
+		for _, o := range orders {
+			o.setPair(pair)
+			out = append(out, o)
+		}
+	}
+	*l = out
+	return nil
+}
+
+func (l OrderList) setPair(pair string) {
+	for i := range l {
+		if l[i].Pair == "" {
+			l[i].setPair(pair)
+		}
+	}
 }
 
 // OrderHistoryResponse represents the response from orderHistory endpoint
 type OrderHistoryResponse struct {
 	BaseResponse
 	Return struct {
-		Orders []OrderHistoryItem `json:"orders"`
+		Orders OrderList `json:"orders"`
 	} `json:"return"`
 }
 
-// OrderHistoryItem represents a single order history item
-type OrderHistoryItem struct {
-	OrderID       string `json:"order_id"`
-	ClientOrderID string `json:"client_order_id,omitempty"`
-	Type          string `json:"type"`
-	Price         string `json:"price"`
-	SubmitTime    string `json:"submit_time"`
-	FinishTime    string `json:"finish_time"`
-	Status        string `json:"status"`
-	OrderBTC      string `json:"order_btc,omitempty"`
-	RemainBTC     string `json:"remain_btc,omitempty"`
-	OrderIDR      string `json:"order_idr,omitempty"`
-	RemainIDR     string `json:"remain_idr,omitempty"`
-	ReceiveIDR    string `json:"receive_idr,omitempty"`
-	ReceiveBTC    string `json:"receive_btc,omitempty"`
-	SpentIDR      string `json:"spent_idr,omitempty"`
-	SpentBTC      string `json:"spent_btc,omitempty"`
-	Fee           string `json:"fee,omitempty"`
+// Order represents an order as reported by openOrders, orderHistory and
+// getOrder. FinishTime is zero while the order is open.
+type Order struct {
+	OrderID       int64           `json:"order_id"`
+	ClientOrderID string          `json:"client_order_id,omitempty"`
+	Pair          string          `json:"pair,omitempty"`
+	Type          string          `json:"type"`
+	OrderType     string          `json:"order_type,omitempty"`
+	Price         decimal.Decimal `json:"price"`
+	Status        string          `json:"status,omitempty"`
+	Fee           decimal.Decimal `json:"fee"`
+	SubmitTime    UnixSeconds     `json:"submit_time"`
+	FinishTime    UnixSeconds     `json:"finish_time"`
+	OrderAmounts
+	coins coinAmounts
+}
+
+func (o *Order) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("order: %w", err)
+	}
+	err := decodeFields(raw, []field{
+		{"order_id", &o.OrderID},
+		{"client_order_id", &o.ClientOrderID},
+		{"pair", &o.Pair},
+		{"type", &o.Type},
+		{"order_type", &o.OrderType},
+		{"price", &o.Price},
+		{"status", &o.Status},
+		{"fee", &o.Fee},
+		{"submit_time", &o.SubmitTime},
+		{"finish_time", &o.FinishTime},
+	})
+	if err != nil {
+		return fmt.Errorf("order %d: %w", o.OrderID, err)
+	}
+	if o.coins, err = collectCoins(raw); err != nil {
+		return fmt.Errorf("order %d: %w", o.OrderID, err)
+	}
+	o.setPair(o.Pair)
+	return nil
+}
+
+func (o *Order) setPair(pair string) {
+	o.Pair = pair
+	o.resolve(pair, o.coins)
 }
 
 // GetOrderResponse represents the response from getOrder endpoint
 type GetOrderResponse struct {
 	BaseResponse
 	Return struct {
-		Order OrderDetail `json:"order"`
+		Order Order `json:"order"`
 	} `json:"return"`
 }
 
-// OrderDetail represents a single order detail
-type OrderDetail struct {
-	OrderID       string `json:"order_id"`
-	Price         string `json:"price"`
-	Type          string `json:"type"`
-	OrderRP       string `json:"order_rp,omitempty"`
-	RemainRP      string `json:"remain_rp,omitempty"`
-	SubmitTime    string `json:"submit_time"`
-	FinishTime    string `json:"finish_time"`
-	Status        string `json:"status"`
-	ReceiveIDR    string `json:"receive_idr,omitempty"`
-	ClientOrderID string `json:"client_order_id,omitempty"`
-}
-
 // CancelOrderResponse represents the response from cancelOrder endpoint
 type CancelOrderResponse struct {
 	BaseResponse
-	Return struct {
-		OrderID       string `json:"order_id"`
-		ClientOrderID string `json:"client_order_id,omitempty"`
-		Type          string `json:"type"`
-		Price         string `json:"price"`
-		SubmitTime    string `json:"submit_time"`
-		FinishTime    string `json:"finish_time"`
-		Status        string `json:"status"`
-		OrderBTC      string `json:"order_btc,omitempty"`
-		RemainBTC     string `json:"remain_btc,omitempty"`
-		OrderIDR      string `json:"order_idr,omitempty"`
-		RemainIDR     string `json:"remain_idr,omitempty"`
-		ReceiveIDR    string `json:"receive_idr,omitempty"`
-		ReceiveBTC    string `json:"receive_btc,omitempty"`
-		SpentIDR      string `json:"spent_idr,omitempty"`
-		SpentBTC      string `json:"spent_btc,omitempty"`
-		Fee           string `json:"fee,omitempty"`
-	} `json:"return"`
+	Return CancelResult `json:"return"`
+}
+
+// CancelResult is a cancelled order and the balances after it. Indodax
+// sends held amounts in the balance as frozen_<coin>; they are Frozen here.
+type CancelResult struct {
+	OrderID       int64                      `json:"order_id"`
+	ClientOrderID string                     `json:"client_order_id,omitempty"`
+	Type          string                     `json:"type"`
+	Pair          string                     `json:"pair"`
+	Balance       map[string]decimal.Decimal `json:"balance"`
+	Frozen        map[string]decimal.Decimal `json:"frozen"`
+}
+
+func (r *CancelResult) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("cancel: %w", err)
+	}
+	var balance map[string]decimal.Decimal
+	err := decodeFields(raw, []field{
+		{"order_id", &r.OrderID},
+		{"client_order_id", &r.ClientOrderID},
+		{"type", &r.Type},
+		{"pair", &r.Pair},
+		{"balance", &balance},
+	})
+	if err != nil {
+		return fmt.Errorf("cancel: %w", err)
+	}
+	r.Balance = make(map[string]decimal.Decimal, len(balance))
+	r.Frozen = make(map[string]decimal.Decimal)
+	for key, v := range balance {
+		if coin, ok := strings.CutPrefix(key, "frozen_"); ok {
+			r.Frozen[coin] = v
+		} else {
+			r.Balance[key] = v
+		}
+	}
+	return nil
 }
 
 // WithdrawFeeResponse represents the response from withdrawFee endpoint
 type WithdrawFeeResponse struct {
 	BaseResponse
 	Return struct {
-		Fee string `json:"fee"`
+		ServerTime  UnixSeconds     `json:"server_time"`
+		WithdrawFee decimal.Decimal `json:"withdraw_fee"`
+		Currency    string          `json:"currency"`
 	} `json:"return"`
 }
 
 // WithdrawCoinResponse represents the response from withdrawCoin endpoint
 type WithdrawCoinResponse struct {
 	BaseResponse
-	Status           string `json:"status"`
-	WithdrawCurrency string `json:"withdraw_currency"`
-	WithdrawAddress  string `json:"withdraw_address"`
-	WithdrawAmount   string `json:"withdraw_amount"`
-	Fee              string `json:"fee"`
-	AmountAfterFee   string `json:"amount_after_fee"`
-	SubmitTime       string `json:"submit_time"`
-	WithdrawID       string `json:"withdraw_id"`
-	TXID             string `json:"txid"`
-	WithdrawUsername string `json:"withdraw_username,omitempty"`
+	Status           string          `json:"status"`
+	WithdrawCurrency string          `json:"withdraw_currency"`
+	WithdrawAddress  string          `json:"withdraw_address"`
+	WithdrawAmount   decimal.Decimal `json:"withdraw_amount"`
+	Fee              decimal.Decimal `json:"fee"`
+	AmountAfterFee   decimal.Decimal `json:"amount_after_fee"`
+	SubmitTime       UnixSeconds     `json:"submit_time"`
+	WithdrawID       string          `json:"withdraw_id"`
+	TXID             string          `json:"txid"`
+	WithdrawUsername string          `json:"withdraw_username,omitempty"`
 }
 
 // ListDownlineResponse represents the response from listDownline endpoint
 type ListDownlineResponse struct {
 	BaseResponse
 	Return struct {
-		Downlines []Downline `json:"downlines"`
-		Total     int        `json:"total"`
+		CurrPage         int        `json:"curr_page"`
+		TotalPage        int        `json:"total_page"`
+		TotalDataPerPage int        `json:"total_data_per_page"`
+		Total            int        `json:"total"`
+		Data             []Downline `json:"data"`
 	} `json:"return"`
 }
 
+// downlineDateLayout is the format of registration_date, as in
+// "9-Jun-20 15:54".
+const downlineDateLayout = "2-Jan-06 15:04"
+
 // Downline represents a single downline
 type Downline struct {
-	UserID   string `json:"user_id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	JoinDate string `json:"join_date"`
+	Name             string    `json:"name"`
+	Username         string    `json:"username"`
+	RegistrationDate time.Time `json:"registration_date"`
+	EmailVerified    bool      `json:"email_verified"`
+	IDVerified       bool      `json:"id_verified"`
+	Level            string    `json:"level"`
+	Start            string    `json:"start"`
+	End              string    `json:"end"`
+}
+
+func (d *Downline) UnmarshalJSON(b []byte) error {
+	type plain Downline
+	var v struct {
+		plain
+		RegistrationDate string `json:"registration_date"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("downline: %w", err)
+	}
+	*d = Downline(v.plain)
+	if v.RegistrationDate != "" {
+		t, err := time.Parse(downlineDateLayout, v.RegistrationDate)
+		if err != nil {
+			return fmt.Errorf("downline %s registration_date: %w", d.Username, err)
+		}
+		d.RegistrationDate = t
+	}
+	return nil
 }
 
 // CheckDownlineResponse represents the response from checkDownline endpoint
 type CheckDownlineResponse struct {
 	BaseResponse
-	Return struct {
-		IsDownline bool `json:"is_downline"`
-	} `json:"return"`
+	IsDownline Flag `json:"is_downline"`
 }
 
 // CreateVoucherResponse represents the response from createVoucher endpoint
 type CreateVoucherResponse struct {
 	BaseResponse
-	Return struct {
-		VoucherID string `json:"voucher_id"`
-		Amount    int    `json:"amount"`
-		ToEmail   string `json:"to_email"`
-	} `json:"return"`
+	WithdrawID int64           `json:"withdraw_id"`
+	RP         decimal.Decimal `json:"rp"`
+	SubmitTime UnixSeconds     `json:"submit_time"`
+	Voucher    string          `json:"voucher"`
 }
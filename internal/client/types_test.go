@@ -0,0 +1,151 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCoinSide(t *testing.T) {
+	tests := []struct {
+		pair, coin string
+		quote, ok  bool
+	}{
+		{"btc_idr", "btc", false, true},
+		{"btc_idr", "idr", true, true},
+		{"btc_idr", "rp", true, true},
+		{"btc_idr", "eth", false, false},
+		{"BTC_IDR", "btc", false, true},
+		{"eth_usdt", "eth", false, true},
+		{"eth_usdt", "usdt", true, true},
+		{"eth_usdt", "idr", false, false},
+		{"btcidr", "btc", false, true},
+		{"btcidr", "idr", true, true},
+		{"ethusdt", "usdt", true, true},
+		{"ethusdt", "btc", false, false},
+		{"", "idr", true, true},
+		{"", "btc", false, true},
+	}
+	for _, tt := range tests {
+		quote, ok := coinSide(tt.pair, tt.coin)
+		if quote != tt.quote || ok != tt.ok {
+			t.Errorf("coinSide(%q, %q) = %v, %v, want %v, %v", tt.pair, tt.coin, quote, ok, tt.quote, tt.ok)
+		}
+	}
+}
+
+func TestCollectCoins(t *testing.T) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal([]byte(`{
+		"order_id": "42",
+		"order_type": "limit",
+		"order_usdt": 25.5,
+		"remain_eth": "0.01",
+		"receive_eth": "",
+		"spend_usdt": null,
+		"price": "2550"
+	}`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coins, err := collectCoins(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"order_usdt":  "25.5",
+		"remain_eth":  "0.01",
+		"receive_eth": "0",
+		"spend_usdt":  "0",
+	}
+	if len(coins) != len(want) {
+		t.Errorf("coins = %v, want keys of %v", coins, want)
+	}
+	for key, v := range want {
+		if got, ok := coins[key]; !ok || !got.Equal(decimal.RequireFromString(v)) {
+			t.Errorf("%s = %s (present %v), want %s", key, got, ok, v)
+		}
+	}
+
+	raw["remain_eth"] = json.RawMessage(`"lots"`)
+	if _, err := collectCoins(raw); err == nil {
+		t.Error("collectCoins accepted a non-numeric amount")
+	}
+}
+
+func TestTradeHistoryItemSetPair(t *testing.T) {
+	tests := []struct {
+		name   string
+		pair   string
+		item   string
+		amount string
+	}{
+		{
+			name:   "idr pair",
+			pair:   "btc_idr",
+			item:   `{"trade_id": "1", "type": "buy", "price": "500000000", "btc": "0.003", "fee": "1500"}`,
+			amount: "0.003",
+		},
+		{
+			name:   "usdt pair",
+			pair:   "eth_usdt",
+			item:   `{"trade_id": 2, "type": "sell", "price": 2500.5, "eth": 0.25, "fee": 0.5}`,
+			amount: "0.25",
+		},
+		{
+			name:   "pair without an underscore",
+			pair:   "btcidr",
+			item:   `{"trade_id": "3", "type": "buy", "price": "500000000", "btc": "0.003", "fee": "1500"}`,
+			amount: "0.003",
+		},
+		{
+			name:   "usdt pair without an underscore",
+			pair:   "ethusdt",
+			item:   `{"trade_id": "4", "type": "sell", "price": "2500", "eth": "0.25", "fee": "0.5"}`,
+			amount: "0.25",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var item TradeHistoryItem
+			if err := json.Unmarshal([]byte(tt.item), &item); err != nil {
+				t.Fatal(err)
+			}
+			if err := item.setPair(tt.pair); err != nil {
+				t.Fatal(err)
+			}
+			if item.Pair != tt.pair || !item.Amount.Equal(decimal.RequireFromString(tt.amount)) {
+				t.Errorf("pair %q amount %s, want %q and %s", item.Pair, item.Amount, tt.pair, tt.amount)
+			}
+			if item.TradeID == 0 || item.Price.IsZero() || item.Fee.IsZero() {
+				t.Errorf("fields not decoded: %+v", item)
+			}
+		})
+	}
+}
+
+func TestOrderAmountsNonIDRQuote(t *testing.T) {
+	var o Order
+	err := json.Unmarshal([]byte(`{
+		"order_id": "7", "pair": "eth_usdt", "type": "buy", "price": "2500",
+		"order_usdt": "100", "remain_usdt": "40", "receive_eth": "0.024"
+	}`), &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !o.OrderQuote.Equal(decimal.NewFromInt(100)) || !o.RemainQuote.Equal(decimal.NewFromInt(40)) ||
+		!o.ReceiveBase.Equal(decimal.RequireFromString("0.024")) {
+		t.Errorf("amounts = %+v", o.OrderAmounts)
+	}
+
+	// a trade reply carries no pair until the request's is set
+	var r TradeResult
+	if err := json.Unmarshal([]byte(`{"order_id": 8, "receive_eth": "0.5", "spend_usdt": "1250"}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	r.setPair("ethusdt")
+	if !r.ReceiveBase.Equal(decimal.RequireFromString("0.5")) || !r.SpentQuote.Equal(decimal.NewFromInt(1250)) {
+		t.Errorf("amounts for ethusdt = %+v", r.OrderAmounts)
+	}
+}
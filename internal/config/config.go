@@ -1,18 +1,84 @@
 package config
 
 import (
+	"encoding/json"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/frederickmarvel/supernova/internal/indicator"
 	"github.com/joho/godotenv"
 )
 
+// Exchanges a TrackedSymbol can be read from.
+const (
+	ExchangeBinance = "binance"
+	ExchangeIndodax = "indodax"
+)
+
+type TrackedSymbol struct {
+	Name   string
+	Symbol string
+	// Exchange supplies the klines: ExchangeBinance (the default) or
+	// ExchangeIndodax, whose symbols are pairs such as btc_idr.
+	Exchange   string
+	Indicators []indicator.Spec
+}
+
 type Config struct {
 	DBName     string
 	DBUser     string
 	DBPassword string
 	DBHost     string
 	DBPort     int
+	// MigrateOnStart applies pending schema migrations before serving or
+	// backfilling (MIGRATE_ON_START, default true).
+	MigrateOnStart bool
+	// StoreDriver selects the trend store: "postgres" (default) or "memory",
+	// which keeps everything in-process and needs no database (STORE_DRIVER).
+	StoreDriver string
+
+	// TrendSymbols is parsed from TREND_SYMBOLS ("bitcoin:BTCUSDT,ethereum:ETHUSDT").
+	// A symbol may name its exchange, as in "bitcoin-idr:btc_idr@indodax".
+	// It seeds the tracked_symbols registry when that is empty; afterwards
+	// symbols are managed through the API.
+	TrendSymbols []TrackedSymbol
+	// TrendIndicators is the JSON list in TREND_INDICATORS, e.g.
+	// [{"name":"ewma"},{"name":"rsi","params":{"period":14}}]. It applies to
+	// symbols without their own indicator list.
+	TrendIndicators []indicator.Spec
+	// TrendIntervals lists the kline intervals updated by default, from the
+	// comma-separated TREND_INTERVALS ("1d,4h").
+	TrendIntervals []string
+	// EWMAProfiles are read from the JSON file at EWMA_PROFILES_FILE, a map
+	// of profile name to indicator.Profile.
+	EWMAProfiles map[string]indicator.Profile
+
+	// SchedulerEnabled runs trend updates in-process (SCHEDULER_ENABLED).
+	SchedulerEnabled bool
+	// TrendSchedules overrides the candle-close cron per interval, from
+	// TREND_SCHEDULE ("1d=0 0 * * *;4h=5 */4 * * *").
+	TrendSchedules  map[string]string
+	SchedulerJitter time.Duration
+	SchedulerDelay  time.Duration
+
+	// IndodaxURL overrides the Indodax public API endpoint (INDODAX_PUBLIC_URL).
+	IndodaxURL string
+	// BinanceURL overrides the Binance REST endpoint, e.g. the testnet or a
+	// local fake (BINANCE_URL).
+	BinanceURL string
+	// StreamIntervals are kept current from Binance kline WebSocket streams,
+	// recomputing each symbol as its candle closes (STREAM_INTERVALS, "1h,4h").
+	StreamIntervals []string
+
+	// LeaderElection gates scheduled jobs on holding a Postgres advisory
+	// lock (LEADER_ELECTION), so only one replica runs them. LeaderLock
+	// names the lock (LEADER_LOCK).
+	LeaderElection bool
+	LeaderLock     string
+	LeaderInterval time.Duration
 }
 
 func Load() *Config {
@@ -21,11 +87,118 @@ func Load() *Config {
 	if err != nil {
 		port = 5432
 	}
+	var indicators []indicator.Spec
+	if raw := os.Getenv("TREND_INDICATORS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &indicators); err != nil {
+			log.Fatalf("TREND_INDICATORS: %v", err)
+		}
+	}
+	var profiles map[string]indicator.Profile
+	if path := os.Getenv("EWMA_PROFILES_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("EWMA_PROFILES_FILE: %v", err)
+		}
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			log.Fatalf("EWMA_PROFILES_FILE: %v", err)
+		}
+	}
+	migrateOnStart, err := strconv.ParseBool(os.Getenv("MIGRATE_ON_START"))
+	if err != nil {
+		migrateOnStart = true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("SCHEDULER_ENABLED"))
+	election, _ := strconv.ParseBool(os.Getenv("LEADER_ELECTION"))
+	driver := os.Getenv("STORE_DRIVER")
+	if driver == "" {
+		driver = "postgres"
+	}
+	lock := os.Getenv("LEADER_LOCK")
+	if lock == "" {
+		lock = "supernova-scheduler"
+	}
 	return &Config{
-		DBName:     os.Getenv("DB_NAME"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     port,
+		DBName:          os.Getenv("DB_NAME"),
+		DBUser:          os.Getenv("DB_USER"),
+		DBPassword:      os.Getenv("DB_PASSWORD"),
+		DBHost:          os.Getenv("DB_HOST"),
+		DBPort:          port,
+		MigrateOnStart:  migrateOnStart,
+		StoreDriver:     driver,
+		TrendSymbols:    parseSymbols(os.Getenv("TREND_SYMBOLS")),
+		TrendIndicators: indicators,
+		TrendIntervals:  splitList(os.Getenv("TREND_INTERVALS")),
+		EWMAProfiles:    profiles,
+
+		SchedulerEnabled: enabled,
+		TrendSchedules:   parseSchedules(os.Getenv("TREND_SCHEDULE")),
+		SchedulerJitter:  durationEnv("SCHEDULER_JITTER", 30*time.Second),
+		SchedulerDelay:   durationEnv("SCHEDULER_DELAY", 5*time.Second),
+
+		BinanceURL:      os.Getenv("BINANCE_URL"),
+		IndodaxURL:      os.Getenv("INDODAX_PUBLIC_URL"),
+		StreamIntervals: splitList(os.Getenv("STREAM_INTERVALS")),
+
+		LeaderElection: election,
+		LeaderLock:     lock,
+		LeaderInterval: durationEnv("LEADER_INTERVAL", 5*time.Second),
+	}
+}
+
+func parseSymbols(s string) []TrackedSymbol {
+	var out []TrackedSymbol
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, sym, ok := strings.Cut(part, ":")
+		if !ok {
+			sym = name
+		}
+		sym, exchange, _ := strings.Cut(sym, "@")
+		if !ok {
+			name = strings.ToLower(sym)
+		}
+		exchange = strings.ToLower(strings.TrimSpace(exchange))
+		if exchange == "" {
+			exchange = ExchangeBinance
+		}
+		out = append(out, TrackedSymbol{
+			Name:     strings.TrimSpace(name),
+			Symbol:   strings.ToUpper(strings.TrimSpace(sym)),
+			Exchange: exchange,
+		})
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func parseSchedules(s string) map[string]string {
+	out := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		iv, expr, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		out[strings.TrimSpace(iv)] = strings.TrimSpace(expr)
+	}
+	return out
+}
+
+func durationEnv(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
 	}
+	return d
 }
@@ -0,0 +1,51 @@
+package indicator
+
+import (
+	"math"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+func init() {
+	Register("atr", func(s Spec) (Indicator, error) {
+		period, err := s.intParam("period", 14)
+		if err != nil {
+			return nil, err
+		}
+		return atr{period: period}, nil
+	})
+}
+
+// atr is Wilder's average true range. atr_pct expresses it relative to the
+// latest close so it compares across symbols.
+type atr struct {
+	period int
+}
+
+func (a atr) Lookback() int { return a.period * 10 }
+
+func (a atr) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, a.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	tr := func(i int) float64 {
+		r := s.high[i] - s.low[i]
+		r = math.Max(r, math.Abs(s.high[i]-s.close[i-1]))
+		return math.Max(r, math.Abs(s.low[i]-s.close[i-1]))
+	}
+	n := float64(a.period)
+	var v float64
+	for i := 1; i <= a.period; i++ {
+		v += tr(i)
+	}
+	v /= n
+	for i := a.period + 1; i < len(s.close); i++ {
+		v = (v*(n-1) + tr(i)) / n
+	}
+	out := map[string]float64{}
+	if last := s.close[len(s.close)-1]; last != 0 {
+		out["atr_pct"] = v / last
+	}
+	return newResult(v, out), nil
+}
@@ -0,0 +1,63 @@
+package indicator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+func init() {
+	Register("bollinger", func(s Spec) (Indicator, error) {
+		period, err := s.intParam("period", 20)
+		if err != nil {
+			return nil, err
+		}
+		k := s.param("k", 2)
+		if k <= 0 {
+			return nil, fmt.Errorf("%s: k must be positive", s.Name)
+		}
+		return bollinger{period: period, k: k}, nil
+	})
+}
+
+// bollinger reports %B: 0 at the lower band, 1 at the upper band.
+type bollinger struct {
+	period int
+	k      float64
+}
+
+func (b bollinger) Lookback() int { return b.period }
+
+func (b bollinger) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, b.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	var mean float64
+	for _, c := range s.close {
+		mean += c
+	}
+	mean /= float64(b.period)
+	var variance float64
+	for _, c := range s.close {
+		variance += (c - mean) * (c - mean)
+	}
+	sd := math.Sqrt(variance / float64(b.period))
+	upper, lower := mean+b.k*sd, mean-b.k*sd
+	last := s.close[len(s.close)-1]
+	pctB := 0.5
+	if upper != lower {
+		pctB = (last - lower) / (upper - lower)
+	}
+	out := map[string]float64{
+		"middle": mean,
+		"upper":  upper,
+		"lower":  lower,
+	}
+	// bandwidth is relative to the mean, which is zero for a dead series
+	if mean != 0 {
+		out["bandwidth"] = (upper - lower) / mean
+	}
+	return newResult(pctB, out), nil
+}
@@ -0,0 +1,50 @@
+package indicator
+
+import "github.com/frederickmarvel/supernova/internal/client"
+
+func init() {
+	Register("donchian", func(s Spec) (Indicator, error) {
+		period, err := s.intParam("period", 20)
+		if err != nil {
+			return nil, err
+		}
+		return donchian{period: period}, nil
+	})
+}
+
+// donchian compares the latest close with the channel of the preceding
+// period candles: 1 on an upside breakout, -1 on a downside one, else 0.
+type donchian struct {
+	period int
+}
+
+func (d donchian) Lookback() int { return d.period + 1 }
+
+func (d donchian) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, d.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	upper, lower := s.high[0], s.low[0]
+	for i := 1; i < d.period; i++ {
+		if s.high[i] > upper {
+			upper = s.high[i]
+		}
+		if s.low[i] < lower {
+			lower = s.low[i]
+		}
+	}
+	last := s.close[d.period]
+	var v float64
+	switch {
+	case last > upper:
+		v = 1
+	case last < lower:
+		v = -1
+	}
+	return newResult(v, map[string]float64{
+		"upper":  upper,
+		"lower":  lower,
+		"middle": (upper + lower) / 2,
+	}), nil
+}
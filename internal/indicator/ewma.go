@@ -0,0 +1,200 @@
+package indicator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+// Profile parameterises the EWMA crossover score. Each pair compares
+// mas[a]-mas[b] and contributes its weight times the sign of that diff; the
+// score is normalised by the total absolute weight so it stays in [-1, 1].
+type Profile struct {
+	Lambdas []float64 `json:"lambdas"`
+	NFs     []float64 `json:"nfs"`
+	Pairs   [][2]int  `json:"pairs"`
+	Weights []float64 `json:"weights,omitempty"`
+	Window  int       `json:"window,omitempty"`
+}
+
+const DefaultProfile = "default"
+
+var defaultProfile = Profile{
+	Lambdas: []float64{0.5, 0.757858283, 0.870550563, 0.933032992, 0.965936329, 0.982820599},
+	NFs:     []float64{1.0000, 1.0000, 1.0000, 1.0000, 1.0020, 1.0462},
+	Pairs:   [][2]int{{0, 2}, {1, 3}, {2, 4}, {3, 5}},
+	Weights: []float64{1, 1, 1, 1},
+	Window:  180,
+}
+
+var (
+	profileMu sync.RWMutex
+	profiles  = map[string]Profile{DefaultProfile: defaultProfile}
+)
+
+func (p Profile) validate() error {
+	if len(p.Lambdas) == 0 {
+		return fmt.Errorf("no lambdas")
+	}
+	if len(p.NFs) != len(p.Lambdas) {
+		return fmt.Errorf("%d nfs for %d lambdas", len(p.NFs), len(p.Lambdas))
+	}
+	for i, l := range p.Lambdas {
+		if l <= 0 || l >= 1 {
+			return fmt.Errorf("lambda %d = %v is outside (0, 1)", i, l)
+		}
+		if p.NFs[i] <= 0 {
+			return fmt.Errorf("nf %d = %v must be positive", i, p.NFs[i])
+		}
+	}
+	if len(p.Pairs) == 0 {
+		return fmt.Errorf("no crossover pairs")
+	}
+	for i, pr := range p.Pairs {
+		for _, idx := range pr {
+			if idx < 0 || idx >= len(p.Lambdas) {
+				return fmt.Errorf("pair %d references ma %d of %d", i, idx, len(p.Lambdas))
+			}
+		}
+		if pr[0] == pr[1] {
+			return fmt.Errorf("pair %d compares ma %d with itself", i, pr[0])
+		}
+	}
+	if len(p.Weights) != len(p.Pairs) {
+		return fmt.Errorf("%d weights for %d pairs", len(p.Weights), len(p.Pairs))
+	}
+	if p.totalWeight() == 0 {
+		return fmt.Errorf("weights sum to zero")
+	}
+	if p.Window < 1 {
+		return fmt.Errorf("window must be positive")
+	}
+	return nil
+}
+
+func (p Profile) totalWeight() float64 {
+	var t float64
+	for _, w := range p.Weights {
+		t += math.Abs(w)
+	}
+	return t
+}
+
+// RegisterProfiles validates and installs named EWMA profiles, replacing any
+// with the same name. Omitted weights default to 1 and an omitted window to
+// 180 candles.
+func RegisterProfiles(ps map[string]Profile) error {
+	valid := make(map[string]Profile, len(ps))
+	for name, p := range ps {
+		if p.Weights == nil {
+			p.Weights = make([]float64, len(p.Pairs))
+			for i := range p.Weights {
+				p.Weights[i] = 1
+			}
+		}
+		if p.Window == 0 {
+			p.Window = defaultProfile.Window
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("ewma profile %q: %w", name, err)
+		}
+		valid[name] = p
+	}
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	for name, p := range valid {
+		profiles[name] = p
+	}
+	return nil
+}
+
+func Profiles() []string {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("ewma", func(s Spec) (Indicator, error) {
+		name := s.Profile
+		if name == "" {
+			name = DefaultProfile
+		}
+		profileMu.RLock()
+		p, ok := profiles[name]
+		profileMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("ewma: unknown profile %q", name)
+		}
+		return ewmaCrossover{p}, nil
+	})
+}
+
+func ewma(prices []float64, lam, nf float64) float64 {
+	weights := make([]float64, len(prices))
+	var sumW float64
+	for i := range prices {
+		w := (1 - lam) * math.Pow(lam, float64(i))
+		weights[i] = w
+		sumW += w
+	}
+	norm := 1 / sumW
+	var total float64
+	for i, price := range prices {
+		total += weights[i] * price * nf
+	}
+	return norm * total
+}
+
+// ewmaCrossover is the regime score: the weighted mean sign of crossovers
+// across a ladder of EWMAs of the close.
+type ewmaCrossover struct {
+	Profile
+}
+
+func (e ewmaCrossover) Lookback() int { return e.Window }
+
+func (e ewmaCrossover) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, e.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	// newest first, so weight i applies to the close i candles ago
+	closes := make([]float64, len(s.close))
+	for i := range s.close {
+		closes[i] = s.close[len(s.close)-1-i]
+	}
+
+	mas := make([]float64, len(e.Lambdas))
+	for i := range e.Lambdas {
+		mas[i] = ewma(closes, e.Lambdas[i], e.NFs[i])
+	}
+	diffs := make([]float64, len(e.Pairs))
+	for i, p := range e.Pairs {
+		diffs[i] = mas[p[0]] - mas[p[1]]
+	}
+	out := map[string]float64{"close": closes[0]}
+	for i, m := range mas {
+		out[fmt.Sprintf("ewma_%d", i)] = m
+	}
+	for i, d := range diffs {
+		out[fmt.Sprintf("diff_%d", i)] = d
+	}
+	var sumSigns float64
+	for i, d := range diffs {
+		if d >= 0 {
+			sumSigns += e.Weights[i]
+		} else {
+			sumSigns -= e.Weights[i]
+		}
+	}
+	return newResult(sumSigns/e.totalWeight(), out), nil
+}
@@ -0,0 +1,147 @@
+package indicator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+var (
+	ErrUnknownIndicator = errors.New("unknown indicator")
+	ErrNotEnoughData    = errors.New("not enough klines")
+)
+
+// Indicator turns a kline series, ordered oldest first, into named outputs.
+type Indicator interface {
+	// Lookback is the number of trailing klines Compute needs.
+	Lookback() int
+	Compute(klines []client.Kline) (Result, error)
+}
+
+// Result holds the headline Value plus every named output. Outputs always
+// contains "value" as well.
+type Result struct {
+	Value   float64
+	Outputs map[string]float64
+}
+
+func newResult(value float64, outputs map[string]float64) Result {
+	if outputs == nil {
+		outputs = make(map[string]float64)
+	}
+	outputs["value"] = value
+	return Result{Value: value, Outputs: outputs}
+}
+
+// Spec selects an indicator and its parameters. ID distinguishes two specs of
+// the same indicator on one symbol (e.g. rsi with periods 7 and 14). Profile
+// names a registered parameter set for indicators that use one (ewma).
+type Spec struct {
+	ID      string             `json:"id,omitempty"`
+	Name    string             `json:"name"`
+	Profile string             `json:"profile,omitempty"`
+	Params  map[string]float64 `json:"params,omitempty"`
+}
+
+// Key is the name readings of this spec are stored under: the ID if set,
+// otherwise "name" or "name:profile".
+func (s Spec) Key() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	if s.Profile != "" {
+		return s.Name + ":" + s.Profile
+	}
+	return s.Name
+}
+
+func (s Spec) param(name string, def float64) float64 {
+	if v, ok := s.Params[name]; ok {
+		return v
+	}
+	return def
+}
+
+// intParam reads a positive integer parameter such as a period.
+func (s Spec) intParam(name string, def int) (int, error) {
+	v := s.param(name, float64(def))
+	if v < 1 || v != float64(int(v)) {
+		return 0, fmt.Errorf("%s: %s must be a positive integer", s.Name, name)
+	}
+	return int(v), nil
+}
+
+type Factory func(spec Spec) (Indicator, error)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Factory{}
+)
+
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = f
+}
+
+func New(spec Spec) (Indicator, error) {
+	mu.RLock()
+	f, ok := registry[spec.Name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownIndicator, spec.Name)
+	}
+	return f(spec)
+}
+
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate builds every spec once and rejects duplicate keys.
+func Validate(specs []Spec) error {
+	seen := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		if _, err := New(s); err != nil {
+			return err
+		}
+		if seen[s.Key()] {
+			return fmt.Errorf("duplicate indicator key %q", s.Key())
+		}
+		seen[s.Key()] = true
+	}
+	return nil
+}
+
+type series struct {
+	high, low, close []float64
+}
+
+// parseSeries converts the trailing n klines to floats.
+func parseSeries(klines []client.Kline, n int) (series, error) {
+	if len(klines) < n {
+		return series{}, ErrNotEnoughData
+	}
+	klines = klines[len(klines)-n:]
+	s := series{
+		high:  make([]float64, n),
+		low:   make([]float64, n),
+		close: make([]float64, n),
+	}
+	for i, k := range klines {
+		s.high[i] = k.HighFloat()
+		s.low[i] = k.LowFloat()
+		s.close[i] = k.CloseFloat()
+	}
+	return s, nil
+}
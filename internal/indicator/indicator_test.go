@@ -0,0 +1,76 @@
+package indicator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client/binancetest"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+)
+
+// serve puts every golden path on a fake Binance whose clock stands just
+// after the last candle closed.
+func serve(t *testing.T) *binancetest.Server {
+	t.Helper()
+	srv := binancetest.NewServer()
+	t.Cleanup(srv.Close)
+	for name := range binancetest.GoldenPaths {
+		klines, err := binancetest.GoldenKlines(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.SetKlines(name, "1h", klines)
+	}
+	end := binancetest.GoldenEnd()
+	srv.Now = func() time.Time { return end }
+	return srv
+}
+
+func TestGolden(t *testing.T) {
+	srv := serve(t)
+	bn := srv.BinanceClient()
+	got := make(map[string]map[string]map[string]float64)
+	for name := range binancetest.GoldenPaths {
+		klines, err := bn.Klines(context.Background(), name, "1h", binancetest.GoldenCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[name] = make(map[string]map[string]float64)
+		for _, ind := range indicator.Names() {
+			spec := indicator.Spec{Name: ind}
+			i, err := indicator.New(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := i.Compute(klines)
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, ind, err)
+			}
+			if res.Outputs["value"] != res.Value {
+				t.Errorf("%s %s: outputs value %v, Value %v", name, ind, res.Outputs["value"], res.Value)
+			}
+			got[name][spec.Key()] = res.Outputs
+		}
+	}
+	binancetest.Golden(t, "golden.json", got)
+}
+
+func TestNotEnoughData(t *testing.T) {
+	klines, err := binancetest.Generate(binancetest.Path{
+		Shape: binancetest.Trend, Start: binancetest.GoldenStart, Interval: "1h", Count: 5, Price: 100, Seed: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ind := range indicator.Names() {
+		i, err := indicator.New(indicator.Spec{Name: ind})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := i.Compute(klines); !errors.Is(err, indicator.ErrNotEnoughData) {
+			t.Errorf("%s over 5 klines: err = %v, want ErrNotEnoughData", ind, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package indicator
+
+import (
+	"fmt"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+)
+
+func init() {
+	Register("macd", func(s Spec) (Indicator, error) {
+		fast, err := s.intParam("fast", 12)
+		if err != nil {
+			return nil, err
+		}
+		slow, err := s.intParam("slow", 26)
+		if err != nil {
+			return nil, err
+		}
+		signal, err := s.intParam("signal", 9)
+		if err != nil {
+			return nil, err
+		}
+		if fast >= slow {
+			return nil, fmt.Errorf("macd: fast (%d) must be shorter than slow (%d)", fast, slow)
+		}
+		return macd{fast: fast, slow: slow, signal: signal}, nil
+	})
+}
+
+// ema returns the exponential moving average series of prices, seeded with
+// the simple average of the first period values. The result is aligned to
+// prices[period-1:].
+func ema(prices []float64, period int) []float64 {
+	alpha := 2 / float64(period+1)
+	var seed float64
+	for _, p := range prices[:period] {
+		seed += p
+	}
+	out := make([]float64, 0, len(prices)-period+1)
+	out = append(out, seed/float64(period))
+	for _, p := range prices[period:] {
+		prev := out[len(out)-1]
+		out = append(out, prev+alpha*(p-prev))
+	}
+	return out
+}
+
+// macd reports the histogram (macd line minus signal line) as its value.
+type macd struct {
+	fast, slow, signal int
+}
+
+func (m macd) Lookback() int { return (m.slow + m.signal) * 4 }
+
+func (m macd) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, m.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	fast := ema(s.close, m.fast)
+	slow := ema(s.close, m.slow)
+	fast = fast[len(fast)-len(slow):]
+	line := make([]float64, len(slow))
+	for i := range slow {
+		line[i] = fast[i] - slow[i]
+	}
+	sig := ema(line, m.signal)
+	l, sg := line[len(line)-1], sig[len(sig)-1]
+	return newResult(l-sg, map[string]float64{
+		"macd":      l,
+		"signal":    sg,
+		"histogram": l - sg,
+	}), nil
+}
@@ -0,0 +1,56 @@
+package indicator
+
+import "github.com/frederickmarvel/supernova/internal/client"
+
+func init() {
+	Register("rsi", func(s Spec) (Indicator, error) {
+		period, err := s.intParam("period", 14)
+		if err != nil {
+			return nil, err
+		}
+		return rsi{period: period}, nil
+	})
+}
+
+// rsi is Wilder's relative strength index. Extra history beyond the period
+// lets the smoothed averages settle.
+type rsi struct {
+	period int
+}
+
+func (r rsi) Lookback() int { return r.period * 10 }
+
+func (r rsi) Compute(klines []client.Kline) (Result, error) {
+	s, err := parseSeries(klines, r.Lookback())
+	if err != nil {
+		return Result{}, err
+	}
+	var gain, loss float64
+	for i := 1; i <= r.period; i++ {
+		d := s.close[i] - s.close[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	n := float64(r.period)
+	gain /= n
+	loss /= n
+	for i := r.period + 1; i < len(s.close); i++ {
+		d := s.close[i] - s.close[i-1]
+		var g, l float64
+		if d > 0 {
+			g = d
+		} else {
+			l = -d
+		}
+		gain = (gain*(n-1) + g) / n
+		loss = (loss*(n-1) + l) / n
+	}
+	v := 100.0
+	if loss != 0 {
+		v = 100 - 100/(1+gain/loss)
+	}
+	return newResult(v, map[string]float64{"avg_gain": gain, "avg_loss": loss}), nil
+}
@@ -0,0 +1,127 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Elector holds a Postgres session-level advisory lock on a dedicated
+// connection. Whoever holds the lock is the leader; when that connection
+// drops Postgres releases the lock and another replica picks it up on its
+// next attempt.
+type Elector struct {
+	db       *sql.DB
+	key      int64
+	interval time.Duration
+
+	// conn is only used by the Run goroutine; others read leader, so a
+	// stalled Postgres never blocks IsLeader.
+	conn   *sql.Conn
+	leader atomic.Bool
+	done   chan struct{}
+}
+
+// KeyFor derives an advisory lock key from a name so unrelated elections
+// in the same database don't collide.
+func KeyFor(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// New returns an elector that tries to take (or verifies it still holds)
+// the lock every interval. Each attempt must finish within the interval;
+// one that times out gives up the lock.
+func New(db *sql.DB, key int64, interval time.Duration) *Elector {
+	return &Elector{db: db, key: key, interval: interval, done: make(chan struct{})}
+}
+
+// Done is closed once Run has returned and the lock is released.
+func (e *Elector) Done() <-chan struct{} {
+	return e.done
+}
+
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns until ctx is cancelled, then releases the lock.
+func (e *Elector) Run(ctx context.Context) {
+	defer close(e.done)
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+	for {
+		e.tick(ctx)
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+	if e.conn != nil {
+		// Still leader as long as the session holding the lock is alive.
+		if err := e.conn.PingContext(ctx); err != nil {
+			log.Printf("leader: lost lock connection: %v", err)
+			e.drop()
+		}
+		return
+	}
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		log.Printf("leader: %v", err)
+		return
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&ok); err != nil {
+		log.Printf("leader: try lock: %v", err)
+		// the lock may have been granted before the call failed
+		abandon(conn)
+		return
+	}
+	if !ok {
+		conn.Close()
+		return
+	}
+	e.conn = conn
+	e.leader.Store(true)
+	log.Printf("leader: acquired lock %d", e.key)
+}
+
+// drop reports the lock lost and abandons its connection.
+func (e *Elector) drop() {
+	e.leader.Store(false)
+	abandon(e.conn)
+	e.conn = nil
+}
+
+// abandon closes conn without returning it to the pool, so a session that
+// might still hold the lock is never reused for other queries.
+func abandon(conn *sql.Conn) {
+	// Returning ErrBadConn makes database/sql close the session.
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
+
+func (e *Elector) resign() {
+	if e.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := e.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+		log.Printf("leader: unlock: %v", err)
+	}
+	e.drop()
+	log.Printf("leader: released lock %d", e.key)
+}
@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+// lockKey serialises migrations across replicas starting at the same time.
+const lockKey = 7_514_220_001
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Load reads the embedded NNNN_name.up.sql / NNNN_name.down.sql pairs in
+// version order.
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		base, dir, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (dir != "up" && dir != "down") {
+			return nil, fmt.Errorf("migration %s: want NNNN_name.up.sql or .down.sql", name)
+		}
+		num, label, _ := strings.Cut(base, "_")
+		v, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", name, err)
+		}
+		body, err := files.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[v]
+		if m == nil {
+			m = &Migration{Version: v, Name: label}
+			byVersion[v] = m
+		}
+		if dir == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// withLock runs f on a single connection holding the migration lock.
+func withLock(ctx context.Context, db *sql.DB, f func(*sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+	_, err = conn.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        )`,
+	)
+	if err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+func applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		out[v] = at
+	}
+	return out, rows.Err()
+}
+
+// run executes one script and records or removes its version in the same
+// transaction.
+func run(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	script, record := m.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	args := []interface{}{m.Version, m.Name}
+	if !up {
+		script, record = m.Down, `DELETE FROM schema_migrations WHERE version = $1`
+		args = args[:1]
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration and returns the ones it ran.
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		have, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			if _, ok := have[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m, true); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first.
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		have, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			m := all[i]
+			if _, ok := have[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s is irreversible", m.Version, m.Name)
+			}
+			if err := run(ctx, conn, m, false); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// List reports every known migration and when it was applied.
+func List(ctx context.Context, db *sql.DB) ([]Status, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	var out []Status
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		have, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			st := Status{Version: m.Version, Name: m.Name}
+			if at, ok := have[m.Version]; ok {
+				st.AppliedAt = &at
+			}
+			out = append(out, st)
+		}
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,2 @@
+-- trend_indicator may predate the migrations and hold history written before
+-- them, so reverting only forgets the version and leaves the table in place.
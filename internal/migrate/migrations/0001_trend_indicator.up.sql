@@ -0,0 +1,8 @@
+-- Original wide table, one column per coin. Kept so existing deployments
+-- retain their history; nothing writes to it any more.
+CREATE TABLE IF NOT EXISTS trend_indicator (
+    bitcoin_trend FLOAT,
+    ethereum_trend FLOAT,
+    solana_trend FLOAT,
+    timestamp TIMESTAMP
+);
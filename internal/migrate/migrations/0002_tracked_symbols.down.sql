@@ -0,0 +1 @@
+DROP TABLE tracked_symbols;
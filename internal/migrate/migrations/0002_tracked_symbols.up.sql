@@ -0,0 +1,6 @@
+CREATE TABLE tracked_symbols (
+    symbol TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    indicators JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,12 @@
+CREATE TABLE trend_readings (
+    symbol TEXT NOT NULL,
+    indicator TEXT NOT NULL DEFAULT 'ewma',
+    interval TEXT NOT NULL DEFAULT '1d',
+    value FLOAT NOT NULL,
+    detail JSONB,
+    config JSONB,
+    timestamp TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX trend_readings_key
+    ON trend_readings (symbol, indicator, interval, timestamp);
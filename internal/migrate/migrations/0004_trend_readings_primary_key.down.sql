@@ -0,0 +1,6 @@
+DROP INDEX trend_readings_interval_ts;
+
+ALTER TABLE trend_readings DROP CONSTRAINT trend_readings_pkey;
+
+CREATE UNIQUE INDEX trend_readings_key
+    ON trend_readings (symbol, indicator, interval, timestamp);
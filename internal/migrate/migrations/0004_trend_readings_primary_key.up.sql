@@ -0,0 +1,4 @@
+ALTER TABLE trend_readings
+    ADD CONSTRAINT trend_readings_pkey PRIMARY KEY USING INDEX trend_readings_key;
+
+CREATE INDEX trend_readings_interval_ts ON trend_readings (interval, timestamp DESC);
@@ -0,0 +1 @@
+ALTER TABLE tracked_symbols DROP COLUMN exchange;
@@ -0,0 +1 @@
+ALTER TABLE tracked_symbols ADD COLUMN exchange TEXT NOT NULL DEFAULT 'binance';
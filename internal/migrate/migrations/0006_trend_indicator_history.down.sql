@@ -0,0 +1,8 @@
+-- Readings written by the service carry their config; the copied ones do not.
+DELETE FROM trend_readings r
+USING trend_indicator t
+WHERE r.timestamp = t.timestamp
+  AND r.symbol IN ('BTCUSDT', 'ETHUSDT', 'SOLUSDT')
+  AND r.indicator = 'ewma'
+  AND r.interval = '1d'
+  AND r.config IS NULL;
@@ -0,0 +1,13 @@
+-- Copy the history of the wide trend_indicator table into trend_readings,
+-- where the coins it had a column for are tracked under their Binance
+-- symbols. trend_indicator itself is kept untouched.
+INSERT INTO trend_readings (symbol, indicator, interval, value, timestamp)
+SELECT c.symbol, 'ewma', '1d', c.value, t.timestamp
+FROM trend_indicator t
+CROSS JOIN LATERAL (VALUES
+    ('BTCUSDT', t.bitcoin_trend),
+    ('ETHUSDT', t.ethereum_trend),
+    ('SOLUSDT', t.solana_trend)
+) AS c (symbol, value)
+WHERE t.timestamp IS NOT NULL AND c.value IS NOT NULL
+ON CONFLICT (symbol, indicator, interval, timestamp) DO NOTHING;
@@ -0,0 +1,6 @@
+ALTER TABLE tracked_symbols
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE trend_indicator
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC';
+ALTER TABLE trend_readings
+    ALTER COLUMN timestamp TYPE TIMESTAMP USING timestamp AT TIME ZONE 'UTC';
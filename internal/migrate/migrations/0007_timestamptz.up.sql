@@ -0,0 +1,9 @@
+-- Timestamps become instants. Readings were always written in UTC; the
+-- created_at default came from now() in the session time zone, which is
+-- what a plain conversion assumes.
+ALTER TABLE trend_readings
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC';
+ALTER TABLE trend_indicator
+    ALTER COLUMN timestamp TYPE TIMESTAMPTZ USING timestamp AT TIME ZONE 'UTC';
+ALTER TABLE tracked_symbols
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
@@ -1,36 +1,256 @@
 package router
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+	"github.com/frederickmarvel/supernova/internal/scheduler"
 	"github.com/frederickmarvel/supernova/internal/service"
 	"github.com/gorilla/mux"
 )
 
-func New(db *sql.DB) *mux.Router {
+type symbolBody struct {
+	Name       string           `json:"name"`
+	Symbol     string           `json:"symbol"`
+	Exchange   string           `json:"exchange,omitempty"`
+	Indicators []indicator.Spec `json:"indicators,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// symbolErrorStatus maps an error from AddSymbol or SetIndicators to a
+// status: 400 for a bad request, 404 for an unknown symbol, 502 when the
+// exchange could not be reached and 500 for anything else, such as the store.
+func symbolErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidSymbol):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrSymbolNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUpstream):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseTime accepts RFC3339 or unix seconds; an empty string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// intervalParam reads the interval query parameter, defaulting to the daily
+// interval.
+func intervalParam(q url.Values) (string, error) {
+	iv := q.Get("interval")
+	if iv == "" {
+		return service.DefaultInterval, nil
+	}
+	_, err := client.IntervalDuration(iv)
+	return iv, err
+}
+
+// New builds the HTTP routes. sched may be nil when the in-process
+// scheduler is disabled.
+func New(svc *service.Service, sched *scheduler.Scheduler) *mux.Router {
 	r := mux.NewRouter()
-	r.HandleFunc("/trend/update", func(w http.ResponseWriter, _ *http.Request) {
-		if err := service.UpdateTrends(db); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	r.HandleFunc("/trend/update", func(w http.ResponseWriter, req *http.Request) {
+		var intervals []string
+		if req.URL.Query().Get("interval") != "" {
+			iv, err := intervalParam(req.URL.Query())
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			intervals = []string{iv}
+		}
+		if err := svc.UpdateTrends(req.Context(), intervals...); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
 			return
 		}
 		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	}).Methods("GET")
 
-	r.HandleFunc("/trend/check", func(w http.ResponseWriter, _ *http.Request) {
-		trends, ts, err := service.GetLatest(db)
+	r.HandleFunc("/trend/check", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		iv, err := intervalParam(q)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		trends, ts, err := svc.GetLatest(req.Context(), q.Get("indicator"), iv)
+		if errors.Is(err, service.ErrNoReadings) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
 			return
 		}
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"timestamp": ts,
+			"interval":  iv,
 			"trend":     trends,
 		})
 	}).Methods("GET")
+
+	r.HandleFunc("/trend/history", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		hq := service.HistoryQuery{
+			Symbol:    q.Get("symbol"),
+			Indicator: q.Get("indicator"),
+			Cursor:    q.Get("cursor"),
+		}
+		if hq.Symbol == "" {
+			writeError(w, http.StatusBadRequest, errors.New("symbol is required"))
+			return
+		}
+		var err error
+		if hq.Interval, err = intervalParam(q); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if hq.From, err = parseTime(q.Get("from")); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if hq.To, err = parseTime(q.Get("to")); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if l := q.Get("limit"); l != "" {
+			if hq.Limit, err = strconv.Atoi(l); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		readings, next, err := svc.History(req.Context(), hq)
+		if errors.Is(err, service.ErrInvalidCursor) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"readings":    readings,
+			"next_cursor": next,
+		})
+	}).Methods("GET")
+
+	r.HandleFunc("/trend/{symbol}/detail", func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		iv, err := intervalParam(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		reading, err := svc.GetDetail(req.Context(), mux.Vars(req)["symbol"], q.Get("indicator"), iv)
+		if errors.Is(err, service.ErrNoReadings) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		json.NewEncoder(w).Encode(reading)
+	}).Methods("GET")
+
+	r.HandleFunc("/symbols", func(w http.ResponseWriter, req *http.Request) {
+		syms, err := svc.ListSymbols(req.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		out := make([]symbolBody, len(syms))
+		for i, s := range syms {
+			out[i] = symbolBody{Name: s.Name, Symbol: s.Symbol, Exchange: s.Exchange, Indicators: s.Indicators}
+		}
+		json.NewEncoder(w).Encode(out)
+	}).Methods("GET")
+
+	r.HandleFunc("/symbols", func(w http.ResponseWriter, req *http.Request) {
+		var body symbolBody
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		err := svc.AddSymbol(req.Context(), config.TrackedSymbol{
+			Name:       body.Name,
+			Symbol:     body.Symbol,
+			Exchange:   body.Exchange,
+			Indicators: body.Indicators,
+		})
+		if err != nil {
+			writeError(w, symbolErrorStatus(err), err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}).Methods("POST")
+
+	r.HandleFunc("/symbols/{symbol}", func(w http.ResponseWriter, req *http.Request) {
+		err := svc.RemoveSymbol(req.Context(), mux.Vars(req)["symbol"])
+		if errors.Is(err, service.ErrSymbolNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}).Methods("DELETE")
+
+	r.HandleFunc("/symbols/{symbol}/indicators", func(w http.ResponseWriter, req *http.Request) {
+		var specs []indicator.Spec
+		if err := json.NewDecoder(req.Body).Decode(&specs); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		err := svc.SetIndicators(req.Context(), mux.Vars(req)["symbol"], specs)
+		if err != nil {
+			writeError(w, symbolErrorStatus(err), err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}).Methods("PUT")
+
+	r.HandleFunc("/indicators", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"available":     indicator.Names(),
+			"ewma_profiles": indicator.Profiles(),
+			"default":       service.DefaultIndicators,
+		})
+	}).Methods("GET")
+
+	r.HandleFunc("/scheduler/status", func(w http.ResponseWriter, _ *http.Request) {
+		if sched == nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled": true,
+			"active":  sched.Active(),
+			"jobs":    sched.Status(),
+		})
+	}).Methods("GET")
 	return r
 }
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression
+// (minute hour day-of-month month day-of-week) evaluated in UTC, the
+// timezone exchange candles close in. Fields accept *, n, a-b, lists and
+// /step.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type field struct {
+	min, max int
+}
+
+var fields = []field{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, 0 and 7 are Sunday
+}
+
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q: want %d fields, got %d", expr, len(fields), len(parts))
+	}
+	bits := make([]uint64, len(fields))
+	for i, p := range parts {
+		b, err := parseField(p, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	// fold Sunday=7 onto 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &Schedule{
+		expr:    expr,
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, step := item, 1
+		if r, st, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(st)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", item)
+			}
+			rng, step = r, n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(a)
+			hi, err2 = strconv.Atoi(b)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("bad range %q", item)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", item)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", item, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s *Schedule) String() string { return s.expr }
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	// classic cron: when both day fields are restricted either may match
+	if !s.domStar && !s.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Next returns the first activation strictly after t.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Five years covers every satisfiable expression, including Feb 29.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,240 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxCatchUp bounds how many missed slots a job replays at once;
+// longer gaps are better filled with the backfill command.
+const DefaultMaxCatchUp = 24
+
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	// Delay is added after every slot so the candle has settled on the
+	// exchange before it is read.
+	Delay time.Duration
+	// Run is called once per slot with the slot time.
+	Run func(ctx context.Context, slot time.Time) error
+	// LastSlot, if set, reports the last slot that completed, typically as
+	// recorded in a store shared by every replica; slots after it are caught
+	// up on start and whenever the gate opens.
+	LastSlot   func() (time.Time, error)
+	MaxCatchUp int
+}
+
+type Status struct {
+	Name         string    `json:"name"`
+	Schedule     string    `json:"schedule"`
+	NextRun      time.Time `json:"next_run"`
+	LastSlot     time.Time `json:"last_slot,omitempty"`
+	LastStarted  time.Time `json:"last_started,omitempty"`
+	LastDuration string    `json:"last_duration,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+	Runs         int       `json:"runs"`
+	Failures     int       `json:"failures"`
+	CaughtUp     int       `json:"caught_up"`
+	Skipped      int       `json:"skipped"`
+	Standby      int       `json:"standby"`
+}
+
+type Scheduler struct {
+	jitter time.Duration
+	jobs   []Job
+	gate   func() bool
+
+	mu     sync.Mutex
+	status map[string]*Status
+}
+
+// New returns a scheduler that delays each run by a random amount up to
+// jitter, spreading load when several replicas or jobs share a slot.
+func New(jitter time.Duration) *Scheduler {
+	return &Scheduler{jitter: jitter, status: make(map[string]*Status)}
+}
+
+// SetGate makes every run conditional on gate, e.g. on holding leadership.
+// When the gate opens again a job with LastSlot resumes after the last slot
+// recorded there, so slots the previous leader missed during a failover are
+// replayed, up to MaxCatchUp.
+func (s *Scheduler) SetGate(gate func() bool) {
+	s.gate = gate
+}
+
+// Active reports whether runs are currently allowed by the gate.
+func (s *Scheduler) Active() bool {
+	return s.gate == nil || s.gate()
+}
+
+func (s *Scheduler) Add(j Job) {
+	if j.MaxCatchUp <= 0 {
+		j.MaxCatchUp = DefaultMaxCatchUp
+	}
+	s.jobs = append(s.jobs, j)
+	s.status[j.Name] = &Status{Name: j.Name, Schedule: j.Schedule.String()}
+}
+
+// Start runs every job in its own goroutine until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Status, 0, len(s.status))
+	for _, st := range s.status {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *Scheduler) update(name string, f func(*Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.status[name])
+}
+
+// resumeAfter returns the slot a job should continue after: the one its
+// LastSlot reports if that is earlier than fallback, otherwise fallback.
+func (s *Scheduler) resumeAfter(j Job, fallback time.Time) time.Time {
+	if j.LastSlot == nil {
+		return fallback
+	}
+	prev, err := j.LastSlot()
+	if err != nil {
+		log.Printf("scheduler %s: last slot: %v", j.Name, err)
+		return fallback
+	}
+	if !prev.IsZero() && prev.Before(fallback) {
+		return prev
+	}
+	return fallback
+}
+
+// gatePoll is how often a job in standby checks whether the gate opened,
+// so a new leader catches up without waiting for the next slot.
+var gatePoll = 5 * time.Second
+
+// sleep waits for d, or while in standby until the gate opens. It returns
+// false when ctx is cancelled.
+func (s *Scheduler) sleep(ctx context.Context, d time.Duration, standby bool) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	var poll <-chan time.Time
+	if standby {
+		tk := time.NewTicker(gatePoll)
+		defer tk.Stop()
+		poll = tk.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+			return true
+		case <-poll:
+			if s.Active() {
+				return true
+			}
+		}
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	last := s.resumeAfter(j, time.Now())
+	standby := false
+	for {
+		next := j.Schedule.Next(last)
+		if next.IsZero() {
+			log.Printf("scheduler %s: %s never fires", j.Name, j.Schedule)
+			return
+		}
+		s.update(j.Name, func(st *Status) { st.NextRun = next.Add(j.Delay) })
+		wait := time.Until(next) + j.Delay
+		if s.jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(s.jitter)))
+		}
+		if wait > 0 && !s.sleep(ctx, wait, standby) {
+			return
+		}
+
+		now := time.Now()
+		if !s.Active() {
+			n := 0
+			for slot := next; !slot.After(now); slot = j.Schedule.Next(slot) {
+				last = slot
+				n++
+			}
+			s.update(j.Name, func(st *Status) { st.Standby += n })
+			standby = true
+			continue
+		}
+		resume := last
+		if standby {
+			// Another replica ran the slots that passed in standby, or was
+			// meant to; it may have died before finishing them.
+			standby = false
+			resume = s.resumeAfter(j, next)
+		}
+		from := j.Schedule.Next(resume)
+		if from.After(now) {
+			// the gate opened before anything was due
+			last = resume
+			continue
+		}
+
+		// Every slot that has passed by now is due; replay them oldest
+		// first so stored readings stay in order.
+		slots := []time.Time{from}
+		for n := j.Schedule.Next(from); !n.After(now); n = j.Schedule.Next(n) {
+			slots = append(slots, n)
+		}
+		if skip := len(slots) - j.MaxCatchUp; skip > 0 {
+			log.Printf("scheduler %s: skipping %d missed slots before %s", j.Name, skip, slots[skip].Format(time.RFC3339))
+			slots = slots[skip:]
+			s.update(j.Name, func(st *Status) { st.Skipped += skip })
+		}
+		for i, slot := range slots {
+			if ctx.Err() != nil {
+				return
+			}
+			if !s.Active() {
+				standby = true
+				break
+			}
+			s.run(ctx, j, slot, i < len(slots)-1)
+		}
+		last = slots[len(slots)-1]
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j Job, slot time.Time, catchUp bool) {
+	started := time.Now()
+	err := j.Run(ctx, slot)
+	s.update(j.Name, func(st *Status) {
+		st.Runs++
+		st.LastSlot = slot
+		st.LastStarted = started
+		st.LastDuration = time.Since(started).String()
+		st.LastError = ""
+		if catchUp {
+			st.CaughtUp++
+		}
+		if err != nil {
+			st.Failures++
+			st.LastError = err.Error()
+		}
+	})
+	if err != nil {
+		log.Printf("scheduler %s: slot %s: %v", j.Name, slot.Format(time.RFC3339), err)
+	}
+}
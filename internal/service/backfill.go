@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/store"
+)
+
+type BackfillOptions struct {
+	// Symbols to rebuild; empty means every registered symbol.
+	Symbols  []string
+	Interval string
+	From     time.Time
+	To       time.Time
+}
+
+// Backfill recomputes readings for every candle that closed between From and
+// To. Each reading only sees klines up to and including its own candle and is
+// stored at that candle's close time, so rerunning a range overwrites the
+// same rows. It returns the number of readings written.
+func (s *Service) Backfill(ctx context.Context, opts BackfillOptions) (int, error) {
+	if opts.Interval == "" {
+		opts.Interval = DefaultInterval
+	}
+	dur, err := client.IntervalDuration(opts.Interval)
+	if err != nil {
+		return 0, err
+	}
+	if opts.To.IsZero() || opts.To.After(time.Now()) {
+		opts.To = time.Now()
+	}
+	if !opts.From.Before(opts.To) {
+		return 0, errors.New("backfill: from must be before to")
+	}
+	registered, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return 0, err
+	}
+	symbols := registered
+	if len(opts.Symbols) > 0 {
+		symbols = nil
+		for _, want := range opts.Symbols {
+			s, ok := findSymbol(registered, want)
+			if !ok {
+				return 0, fmt.Errorf("%w: %s", ErrSymbolNotFound, want)
+			}
+			symbols = append(symbols, s)
+		}
+	}
+
+	total := 0
+	for _, sym := range symbols {
+		n, err := s.backfillSymbol(ctx, sym, opts, dur)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("backfill %s: %w", sym.Symbol, err)
+		}
+		log.Printf("backfill %s %s: %d readings", sym.Symbol, opts.Interval, n)
+	}
+	return total, nil
+}
+
+func (s *Service) backfillSymbol(ctx context.Context, sym config.TrackedSymbol, opts BackfillOptions, dur time.Duration) (int, error) {
+	set, err := newIndicatorSet(sym)
+	if err != nil {
+		return 0, err
+	}
+	// The first candle in range closes at From. Load the lookback before it
+	// by count, as computeIndicators does, so both see the same candles
+	// even across a gap.
+	first := opts.From.Add(-dur)
+	history, err := s.klinesBefore(ctx, sym, opts.Interval, first.Add(-time.Millisecond), set.lookback)
+	if err != nil {
+		return 0, err
+	}
+	klines, gaps, err := s.klinesBetween(ctx, sym, opts.Interval, first, opts.To)
+	if err != nil {
+		return 0, err
+	}
+	klines = append(history, klines...)
+	for _, g := range gaps {
+		log.Printf("backfill %s %s: %d candles missing from %s to %s",
+			sym.Symbol, opts.Interval, g.Missing(dur), g.From.UTC().Format(time.RFC3339), g.To.UTC().Format(time.RFC3339))
+	}
+
+	var readings []store.Reading
+	for i, k := range klines {
+		closeAt := k.OpenTime.Add(dur)
+		if closeAt.Before(opts.From) {
+			continue
+		}
+		if closeAt.After(opts.To) {
+			break
+		}
+		results, err := set.compute(klines[:i+1])
+		if err != nil {
+			return 0, err
+		}
+		readings = append(readings, toReadings(sym.Symbol, opts.Interval, closeAt, results)...)
+	}
+	if err := s.store.SaveReadings(ctx, readings); err != nil {
+		return 0, err
+	}
+	return len(readings), nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+)
+
+// klineSource is implemented by the exchange clients trends can be
+// computed from.
+type klineSource interface {
+	KlinesBetween(ctx context.Context, symbol, interval string, start, end time.Time) ([]client.Kline, []client.Gap, error)
+	KlinesBefore(ctx context.Context, symbol, interval string, end time.Time, n int) ([]client.Kline, error)
+}
+
+func exchangeOf(sym config.TrackedSymbol) string {
+	if sym.Exchange == "" {
+		return config.ExchangeBinance
+	}
+	return sym.Exchange
+}
+
+func (s *Service) source(sym config.TrackedSymbol) (klineSource, error) {
+	switch exchangeOf(sym) {
+	case config.ExchangeBinance:
+		return s.binance, nil
+	case config.ExchangeIndodax:
+		return s.indodax, nil
+	}
+	return nil, fmt.Errorf("%s: unknown exchange %q", sym.Symbol, sym.Exchange)
+}
+
+// klinesBetween fetches a symbol's klines from its exchange.
+func (s *Service) klinesBetween(ctx context.Context, sym config.TrackedSymbol, interval string, start, end time.Time) ([]client.Kline, []client.Gap, error) {
+	src, err := s.source(sym)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src.KlinesBetween(ctx, sym.Symbol, interval, start, end)
+}
+
+// klinesBefore fetches a symbol's last n klines opening no later than end.
+func (s *Service) klinesBefore(ctx context.Context, sym config.TrackedSymbol, interval string, end time.Time, n int) ([]client.Kline, error) {
+	src, err := s.source(sym)
+	if err != nil {
+		return nil, err
+	}
+	return src.KlinesBefore(ctx, sym.Symbol, interval, end, n)
+}
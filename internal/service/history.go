@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/store"
+)
+
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type HistoryQuery struct {
+	Symbol    string
+	Indicator string
+	Interval  string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Cursor    string
+}
+
+func encodeCursor(ts time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ts.UTC().Format(time.RFC3339Nano)))
+}
+
+func decodeCursor(c string) (time.Time, error) {
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(b))
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return ts, nil
+}
+
+// History returns readings for one symbol in ascending time order. The
+// returned cursor is empty once the range is exhausted; otherwise it is
+// passed back as q.Cursor to fetch the next page.
+func (s *Service) History(ctx context.Context, q HistoryQuery) ([]store.Reading, string, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultHistoryLimit
+	}
+	if q.Limit > MaxHistoryLimit {
+		q.Limit = MaxHistoryLimit
+	}
+	if q.Indicator == "" {
+		q.Indicator = DefaultIndicator
+	}
+	if q.Interval == "" {
+		q.Interval = DefaultInterval
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	rq := store.RangeQuery{
+		Indicator: q.Indicator,
+		Interval:  q.Interval,
+		After:     q.From,
+		Inclusive: true,
+		To:        q.To,
+		// one extra row tells whether another page exists
+		Limit: q.Limit + 1,
+	}
+	if q.Cursor != "" {
+		ts, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		rq.After = ts
+		rq.Inclusive = false
+	}
+	var err error
+	if rq.Symbol, err = s.resolveSymbol(ctx, q.Symbol); err != nil {
+		return nil, "", err
+	}
+	out, err := s.store.Range(ctx, rq)
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if len(out) > q.Limit {
+		out = out[:q.Limit]
+		next = encodeCursor(out[len(out)-1].Timestamp)
+	}
+	if out == nil {
+		out = []store.Reading{}
+	}
+	return out, next, nil
+}
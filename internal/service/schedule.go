@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/scheduler"
+	"github.com/frederickmarvel/supernova/internal/store"
+)
+
+// defaultSchedules fire on the UTC candle boundaries Binance closes klines
+// on. 3d candles are epoch-aligned rather than calendar-aligned, so they have
+// no cron equivalent and need an explicit schedule.
+var defaultSchedules = map[string]string{
+	"1m":  "* * * * *",
+	"3m":  "*/3 * * * *",
+	"5m":  "*/5 * * * *",
+	"15m": "*/15 * * * *",
+	"30m": "*/30 * * * *",
+	"1h":  "0 * * * *",
+	"2h":  "0 */2 * * *",
+	"4h":  "0 */4 * * *",
+	"6h":  "0 */6 * * *",
+	"8h":  "0 */8 * * *",
+	"12h": "0 */12 * * *",
+	"1d":  "0 0 * * *",
+	"1w":  "0 0 * * 1",
+}
+
+// UpdateJob returns a scheduler job that stores readings for interval at
+// every slot of expr, or of the candle-close schedule when expr is empty.
+func (s *Service) UpdateJob(interval, expr string, delay time.Duration) (scheduler.Job, error) {
+	if _, err := client.IntervalDuration(interval); err != nil {
+		return scheduler.Job{}, err
+	}
+	if expr == "" {
+		expr = defaultSchedules[interval]
+		if expr == "" {
+			return scheduler.Job{}, fmt.Errorf("no default schedule for interval %s", interval)
+		}
+	}
+	sched, err := scheduler.Parse(expr)
+	if err != nil {
+		return scheduler.Job{}, err
+	}
+	return scheduler.Job{
+		Name:     "trend_" + interval,
+		Schedule: sched,
+		Delay:    delay,
+		Run: func(ctx context.Context, slot time.Time) error {
+			return s.UpdateAt(ctx, interval, slot)
+		},
+		// Only readings stamped at a slot of this job count, so a reading
+		// written off the grid cannot hide the slots missed before it.
+		LastSlot: func() (time.Time, error) {
+			return s.store.LastTimestamp(context.Background(), interval, func(t time.Time) bool {
+				return sched.Next(t.Add(-time.Second)).Equal(t)
+			})
+		},
+	}, nil
+}
+
+// UpdateAt stores readings for every registered symbol as of at, using only
+// candles that had closed by then. Like Backfill it upserts, so repeating a
+// slot is harmless. A symbol that fails does not hold back the others: their
+// readings are saved and the failures are returned together.
+func (s *Service) UpdateAt(ctx context.Context, interval string, at time.Time) error {
+	if _, err := client.IntervalDuration(interval); err != nil {
+		return err
+	}
+	symbols, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	if symbols, err = s.tradable(ctx, symbols); err != nil {
+		return err
+	}
+	var readings []store.Reading
+	var errs []error
+	for _, sym := range symbols {
+		res, err := s.readingsAt(ctx, sym, interval, at)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sym.Symbol, err))
+			continue
+		}
+		readings = append(readings, res...)
+	}
+	if err := s.store.SaveReadings(ctx, readings); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// readingsAt computes the readings of one symbol from the candles that had
+// closed by at, stamped with at.
+func (s *Service) readingsAt(ctx context.Context, sym config.TrackedSymbol, interval string, at time.Time) ([]store.Reading, error) {
+	res, err := s.computeIndicators(ctx, sym, interval, at)
+	if err != nil {
+		return nil, err
+	}
+	return toReadings(sym.Symbol, interval, at, res), nil
+}
+
+// computeIndicators fetches the last candles of one interval that had
+// closed by at, enough for the longest lookback, and runs every configured
+// indicator over them. They are fetched by count rather than by time, so a
+// gap in the exchange's data does not leave the indicators short, and the
+// input matches what Backfill computes the same slot from.
+func (s *Service) computeIndicators(ctx context.Context, sym config.TrackedSymbol, interval string, at time.Time) ([]computed, error) {
+	dur, err := client.IntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+	set, err := newIndicatorSet(sym)
+	if err != nil {
+		return nil, err
+	}
+	// the newest candle that had closed by at opened one interval earlier
+	klines, err := s.klinesBefore(ctx, sym, interval, at.Add(-dur), set.lookback+1)
+	if err != nil {
+		return nil, err
+	}
+	for len(klines) > 0 && klines[len(klines)-1].OpenTime.Add(dur).After(at) {
+		klines = klines[:len(klines)-1]
+	}
+	out, err := set.compute(klines)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) < len(set.specs) {
+		log.Printf("%s %s: %d of %d indicators lacked history", sym.Symbol, interval, len(set.specs)-len(out), len(set.specs))
+	}
+	return out, nil
+}
+
+// weekOffset is how far Binance's weekly candles, which open on Monday, are
+// shifted from the Thursday unix epoch that every shorter interval is
+// aligned to.
+const weekOffset = 4 * 24 * time.Hour
+
+// lastClosedBoundary returns the close time of the newest candle of
+// interval that had closed by now.
+func lastClosedBoundary(interval string, now time.Time) (time.Time, error) {
+	dur, err := client.IntervalDuration(interval)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var offset time.Duration
+	if interval == "1w" {
+		offset = weekOffset
+	}
+	since := now.Sub(time.Unix(0, 0).Add(offset))
+	return time.Unix(0, 0).Add(offset + since - since%dur).UTC(), nil
+}
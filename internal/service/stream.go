@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+)
+
+// Watch recomputes a symbol's readings as soon as its kline stream reports a
+// closed candle, instead of waiting for a scheduled or polled update. It
+// covers the Binance symbols registered when it starts and blocks until ctx
+// is cancelled.
+func (s *Service) Watch(ctx context.Context, interval string) error {
+	dur, err := client.IntervalDuration(interval)
+	if err != nil {
+		return err
+	}
+	symbols, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, sym := range symbols {
+		if exchangeOf(sym) != config.ExchangeBinance {
+			continue
+		}
+		candles, err := client.NewKlineStream(s.binance, sym.Symbol, interval).Subscribe(ctx)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(sym config.TrackedSymbol) {
+			defer wg.Done()
+			for k := range candles {
+				at := k.OpenTime.Add(dur)
+				if err := s.updateSymbolAt(ctx, sym, interval, at); err != nil {
+					log.Printf("stream update %s %s at %s: %v", sym.Symbol, interval, at.UTC().Format(time.RFC3339), err)
+				}
+			}
+		}(sym)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *Service) updateSymbolAt(ctx context.Context, sym config.TrackedSymbol, interval string, at time.Time) error {
+	readings, err := s.readingsAt(ctx, sym, interval, at)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveReadings(ctx, readings)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+	"github.com/frederickmarvel/supernova/internal/store"
+)
+
+var ErrSymbolNotFound = store.ErrSymbolNotFound
+
+var (
+	// ErrInvalidSymbol is wrapped by AddSymbol and SetIndicators when the
+	// request itself is wrong: a missing field, a bad indicator, an unknown
+	// exchange or a symbol the exchange does not list.
+	ErrInvalidSymbol = errors.New("invalid symbol")
+	// ErrUpstream is wrapped by AddSymbol when the exchange could not be
+	// asked whether the symbol exists.
+	ErrUpstream = errors.New("exchange unavailable")
+)
+
+// DefaultSymbols seeds an empty registry when TREND_SYMBOLS is not set.
+var DefaultSymbols = []config.TrackedSymbol{
+	{Name: "bitcoin", Symbol: "BTCUSDT"},
+	{Name: "ethereum", Symbol: "ETHUSDT"},
+	{Name: "solana", Symbol: "SOLUSDT"},
+}
+
+// InitSymbols seeds the registry at startup from syms, or from
+// DefaultSymbols when syms is empty. Once anything is registered the
+// registry is left alone, so symbols and indicators changed through the API
+// survive restarts. Either way every symbol must have candles for each of
+// Intervals on its exchange.
+func (s *Service) InitSymbols(ctx context.Context, syms []config.TrackedSymbol) error {
+	have, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return err
+	}
+	seed := len(have) == 0
+	if seed {
+		if len(syms) == 0 {
+			syms = DefaultSymbols
+		}
+		have = syms
+	}
+	var errs []error
+	for _, sym := range have {
+		errs = append(errs, checkIntervals(sym))
+	}
+	if err := errors.Join(errs...); err != nil || !seed {
+		return err
+	}
+	return s.store.ReplaceSymbols(ctx, syms)
+}
+
+func (s *Service) ListSymbols(ctx context.Context) ([]config.TrackedSymbol, error) {
+	return s.store.ListSymbols(ctx)
+}
+
+func (s *Service) AddSymbol(ctx context.Context, sym config.TrackedSymbol) error {
+	sym.Symbol = strings.ToUpper(strings.TrimSpace(sym.Symbol))
+	sym.Name = strings.TrimSpace(sym.Name)
+	sym.Exchange = strings.ToLower(strings.TrimSpace(sym.Exchange))
+	if sym.Symbol == "" || sym.Name == "" {
+		return fmt.Errorf("%w: name and symbol are required", ErrInvalidSymbol)
+	}
+	if sym.Exchange == "" {
+		sym.Exchange = config.ExchangeBinance
+	}
+	if len(sym.Indicators) > 0 {
+		if err := indicator.Validate(sym.Indicators); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSymbol, err)
+		}
+	}
+	switch sym.Exchange {
+	case config.ExchangeBinance:
+		if _, err := s.binance.Symbol(ctx, sym.Symbol); errors.Is(err, client.ErrInvalidSymbol) {
+			return fmt.Errorf("%w: %w", ErrInvalidSymbol, err)
+		} else if err != nil {
+			return fmt.Errorf("%w: %w", ErrUpstream, err)
+		}
+	case config.ExchangeIndodax:
+		pairs, err := s.indodaxPairs(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUpstream, err)
+		}
+		if _, ok := pairs[indodaxID(sym.Symbol)]; !ok {
+			return fmt.Errorf("%w: %s is not an indodax pair", ErrInvalidSymbol, sym.Symbol)
+		}
+	default:
+		return fmt.Errorf("%w: unknown exchange %q", ErrInvalidSymbol, sym.Exchange)
+	}
+	if err := checkIntervals(sym); err != nil {
+		return err
+	}
+	return s.store.PutSymbol(ctx, sym)
+}
+
+// checkIntervals rejects a symbol whose exchange has no candles for one of
+// Intervals, as every update of that interval would fail for it.
+func checkIntervals(sym config.TrackedSymbol) error {
+	switch exchangeOf(sym) {
+	case config.ExchangeBinance:
+		return nil
+	case config.ExchangeIndodax:
+		for _, iv := range Intervals {
+			if !client.IndodaxInterval(iv) {
+				return fmt.Errorf("%w: indodax has no %s candles for %s", ErrInvalidSymbol, iv, sym.Symbol)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown exchange %q for %s", ErrInvalidSymbol, sym.Exchange, sym.Symbol)
+	}
+}
+
+// SetIndicators replaces the indicator list of a registered symbol. An empty
+// list reverts it to DefaultIndicators.
+func (s *Service) SetIndicators(ctx context.Context, symbol string, specs []indicator.Spec) error {
+	if len(specs) > 0 {
+		if err := indicator.Validate(specs); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSymbol, err)
+		}
+	}
+	return s.store.SetIndicators(ctx, strings.ToUpper(symbol), specs)
+}
+
+func (s *Service) RemoveSymbol(ctx context.Context, symbol string) error {
+	return s.store.DeleteSymbol(ctx, strings.ToUpper(symbol))
+}
+
+// tradable drops symbols their exchange does not list or has halted, so one
+// delisted market does not fail an update for every other symbol.
+func (s *Service) tradable(ctx context.Context, syms []config.TrackedSymbol) ([]config.TrackedSymbol, error) {
+	var bn, idx []config.TrackedSymbol
+	for _, sym := range syms {
+		switch exchangeOf(sym) {
+		case config.ExchangeIndodax:
+			idx = append(idx, sym)
+		default:
+			bn = append(bn, sym)
+		}
+	}
+	out, err := s.tradableBinance(ctx, bn)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx) == 0 {
+		return out, nil
+	}
+	pairs, err := s.indodaxPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sym := range idx {
+		p, ok := pairs[indodaxID(sym.Symbol)]
+		if !ok || !p.Open() {
+			log.Printf("skipping %s: not open on indodax", sym.Symbol)
+			continue
+		}
+		out = append(out, sym)
+	}
+	return out, nil
+}
+
+// indodaxID normalises "BTC_IDR" or "BTCIDR" to the pair id "btcidr".
+func indodaxID(symbol string) string {
+	return strings.ToLower(strings.ReplaceAll(symbol, "_", ""))
+}
+
+func (s *Service) indodaxPairs(ctx context.Context) (map[string]client.Pair, error) {
+	list, err := s.indodax.Pairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make(map[string]client.Pair, len(list))
+	for _, p := range list {
+		pairs[p.ID] = p
+	}
+	return pairs, nil
+}
+
+func (s *Service) tradableBinance(ctx context.Context, syms []config.TrackedSymbol) ([]config.TrackedSymbol, error) {
+	if len(syms) == 0 {
+		return syms, nil
+	}
+	names := make([]string, len(syms))
+	for i, sym := range syms {
+		names[i] = sym.Symbol
+	}
+	info, err := s.binance.ExchangeInfo(ctx, names...)
+	if errors.Is(err, client.ErrInvalidSymbol) {
+		if len(syms) == 1 {
+			log.Printf("skipping %s: not listed on binance", syms[0].Symbol)
+			return nil, nil
+		}
+		// the batch is rejected as a whole, so find the unknown ones
+		var out []config.TrackedSymbol
+		for _, sym := range syms {
+			ok, err := s.tradableBinance(ctx, []config.TrackedSymbol{sym})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, ok...)
+		}
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]client.SymbolInfo, len(info.Symbols))
+	for _, si := range info.Symbols {
+		listed[si.Symbol] = si
+	}
+	var out []config.TrackedSymbol
+	for _, sym := range syms {
+		if si := listed[sym.Symbol]; !si.Trading() {
+			log.Printf("skipping %s: binance status %q", sym.Symbol, si.Status)
+			continue
+		}
+		out = append(out, sym)
+	}
+	return out, nil
+}
+
+// resolveSymbol accepts either a registered name ("bitcoin") or an exchange
+// symbol. Unknown values are returned upper-cased so history for symbols that
+// were removed from the registry stays reachable.
+func (s *Service) resolveSymbol(ctx context.Context, want string) (string, error) {
+	syms, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return "", err
+	}
+	if sym, ok := findSymbol(syms, want); ok {
+		return sym.Symbol, nil
+	}
+	return strings.ToUpper(want), nil
+}
+
+func findSymbol(syms []config.TrackedSymbol, want string) (config.TrackedSymbol, bool) {
+	for _, s := range syms {
+		if s.Name == want || s.Symbol == strings.ToUpper(want) {
+			return s, true
+		}
+	}
+	return config.TrackedSymbol{}, false
+}
@@ -1,132 +1,212 @@
 package service
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"log"
 	"math"
-	"strconv"
 	"time"
 
 	"github.com/frederickmarvel/supernova/internal/client"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+	"github.com/frederickmarvel/supernova/internal/store"
 )
 
-var lambdas = []float64{0.5, 0.757858283, 0.870550563, 0.933032992, 0.965936329, 0.982820599}
-var nfs = []float64{1.0000, 1.0000, 1.0000, 1.0000, 1.0020, 1.0462}
+const (
+	// DefaultIndicator is the key /trend/check reports when none is requested.
+	DefaultIndicator = "ewma"
+	DefaultInterval  = "1d"
+)
+
+var ErrNoReadings = errors.New("no readings")
+
+// Intervals are the kline intervals UpdateTrends computes when called
+// without an explicit one.
+var Intervals = []string{DefaultInterval}
+
+// DefaultIndicators apply to symbols registered without their own list.
+var DefaultIndicators = []indicator.Spec{{Name: DefaultIndicator}}
+
+// Service computes trend readings and serves them from a store.
+type Service struct {
+	store   store.Store
+	binance *client.BinanceClient
+	indodax *client.IndodaxPublicClient
+}
+
+func New(st store.Store, bn *client.BinanceClient, idx *client.IndodaxPublicClient) *Service {
+	return &Service{store: st, binance: bn, indodax: idx}
+}
 
-func ewma(prices []float64, lam, nf float64) float64 {
-	weights := make([]float64, len(prices))
-	var sumW float64
-	for i := range prices {
-		w := (1 - lam) * math.Pow(lam, float64(i))
-		weights[i] = w
-		sumW += w
+type computed struct {
+	spec   indicator.Spec
+	result indicator.Result
+}
+
+func indicatorsFor(s config.TrackedSymbol) []indicator.Spec {
+	if len(s.Indicators) > 0 {
+		return s.Indicators
 	}
-	norm := 1 / sumW
-	var total float64
-	for i, price := range prices {
-		total += weights[i] * price * nf
+	return DefaultIndicators
+}
+
+type indicatorSet struct {
+	specs    []indicator.Spec
+	inds     []indicator.Indicator
+	lookback int
+}
+
+func newIndicatorSet(s config.TrackedSymbol) (*indicatorSet, error) {
+	set := &indicatorSet{specs: indicatorsFor(s)}
+	for _, spec := range set.specs {
+		ind, err := indicator.New(spec)
+		if err != nil {
+			return nil, err
+		}
+		set.inds = append(set.inds, ind)
+		if ind.Lookback() > set.lookback {
+			set.lookback = ind.Lookback()
+		}
 	}
-	return norm * total
+	return set, nil
 }
 
-func computeIndicator(symbol string) (float64, error) {
-	klines, err := client.FetchKlines(symbol)
-	if err != nil {
-		return 0, err
-	}
-	if len(klines) < 180 {
-		return 0, nil
-	}
-	closes := make([]float64, len(klines))
-	for i := range klines {
-		c, _ := strconv.ParseFloat(klines[len(klines)-1-i].Close, 64)
-		closes[i] = c
-	}
-
-	mas := []float64{
-		ewma(closes, lambdas[0], nfs[0]),
-		ewma(closes, lambdas[1], nfs[1]),
-		ewma(closes, lambdas[2], nfs[2]),
-		ewma(closes, lambdas[3], nfs[3]),
-		ewma(closes, lambdas[4], nfs[4]),
-		ewma(closes, lambdas[5], nfs[5]),
-	}
-	diffs := []float64{
-		mas[0] - mas[2],
-		mas[1] - mas[3],
-		mas[2] - mas[4],
-		mas[3] - mas[5],
-	}
-	var sumSigns float64
-	for _, d := range diffs {
-		if d >= 0 {
-			sumSigns += 1
-		} else {
-			sumSigns -= 1
+// compute runs every indicator over klines. Indicators without enough
+// history, or whose outputs are not finite, are skipped.
+func (set *indicatorSet) compute(klines []client.Kline) ([]computed, error) {
+	var out []computed
+	for i, ind := range set.inds {
+		res, err := ind.Compute(klines)
+		if errors.Is(err, indicator.ErrNotEnoughData) {
+			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := nonFinite(res); ok {
+			log.Printf("%s: skipping reading with non-finite %s", set.specs[i].Key(), key)
+			continue
+		}
+		out = append(out, computed{spec: set.specs[i], result: res})
 	}
-	return sumSigns / 4, nil
+	return out, nil
 }
 
-func UpdateTrends(db *sql.DB) error {
-	now := time.Now()
-	symbols := map[string]string{
-		"bitcoin":  "BTCUSDT",
-		"ethereum": "ETHUSDT",
-		"solana":   "SOLUSDT",
+// nonFinite returns the first output of res that is NaN or infinite. Such a
+// reading cannot be stored as JSON and would fail the whole batch.
+func nonFinite(res indicator.Result) (string, bool) {
+	for key, v := range res.Outputs {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return key, true
+		}
 	}
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	_, err = tx.Exec(
-		`CREATE TABLE IF NOT EXISTS trend_indicator (
-            bitcoin_trend FLOAT,
-            ethereum_trend FLOAT,
-            solana_trend FLOAT,
-            timestamp TIMESTAMP
-        )`,
-	)
-	if err != nil {
-		tx.Rollback()
-		return err
+	return "", false
+}
+
+// toReadings stamps computed results of one symbol and interval with ts.
+func toReadings(symbol, interval string, ts time.Time, results []computed) []store.Reading {
+	out := make([]store.Reading, len(results))
+	for i, c := range results {
+		spec := c.spec
+		out[i] = store.Reading{
+			Symbol:    symbol,
+			Indicator: spec.Key(),
+			Interval:  interval,
+			Value:     c.result.Value,
+			Detail:    c.result.Outputs,
+			Config:    &spec,
+			Timestamp: ts,
+		}
 	}
-	var vals []interface{}
-	for _, sym := range symbols {
-		ind, err := computeIndicator(sym)
+	return out
+}
+
+// UpdateTrends stores readings as of the last candle close for every
+// registered symbol and indicator at each of the given intervals, or at
+// Intervals if none are given. Like a scheduled run it only uses closed
+// candles and stamps readings at the close, so manual updates land on the
+// same grid.
+func (s *Service) UpdateTrends(ctx context.Context, intervals ...string) error {
+	if len(intervals) == 0 {
+		intervals = Intervals
+	}
+	now := time.Now()
+	slots := make([]time.Time, len(intervals))
+	for i, iv := range intervals {
+		at, err := lastClosedBoundary(iv, now)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
-		vals = append(vals, ind)
-	}
-	vals = append(vals, now)
-	_, err = tx.Exec(
-		`INSERT INTO trend_indicator (
-            bitcoin_trend, ethereum_trend, solana_trend, timestamp
-        ) VALUES ($1,$2,$3,$4)`,
-		vals...,
-	)
-	if err != nil {
-		tx.Rollback()
-		return err
+		slots[i] = at
+	}
+	for i, iv := range intervals {
+		if err := s.UpdateAt(ctx, iv, slots[i]); err != nil {
+			return err
+		}
 	}
-	return tx.Commit()
+	return nil
 }
 
-func GetLatest(db *sql.DB) (map[string]float64, time.Time, error) {
-	row := db.QueryRow(
-		`SELECT bitcoin_trend, ethereum_trend, solana_trend, timestamp
-         FROM trend_indicator ORDER BY timestamp DESC LIMIT 1`,
-	)
-	var b, e, s float64
-	var ts time.Time
-	err := row.Scan(&b, &e, &s, &ts)
+// GetLatest returns the most recent reading of the given indicator and
+// interval for every registered symbol, keyed by its name, together with the
+// time of the newest reading.
+func (s *Service) GetLatest(ctx context.Context, ind, interval string) (map[string]float64, time.Time, error) {
+	if ind == "" {
+		ind = DefaultIndicator
+	}
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	symbols, err := s.store.ListSymbols(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	names := make(map[string]string, len(symbols))
+	for _, sym := range symbols {
+		names[sym.Symbol] = sym.Name
+	}
+	readings, err := s.store.Latest(ctx, ind, interval)
 	if err != nil {
 		return nil, time.Time{}, err
 	}
-	return map[string]float64{
-		"bitcoin":  b,
-		"ethereum": e,
-		"solana":   s,
-	}, ts, nil
+	trends := make(map[string]float64)
+	var latest time.Time
+	for _, r := range readings {
+		name, ok := names[r.Symbol]
+		if !ok {
+			continue
+		}
+		trends[name] = r.Value
+		if r.Timestamp.After(latest) {
+			latest = r.Timestamp
+		}
+	}
+	if len(trends) == 0 {
+		return nil, time.Time{}, ErrNoReadings
+	}
+	return trends, latest, nil
+}
+
+// GetDetail returns the newest reading of one indicator and interval for a
+// symbol, including every output and the configuration it was computed with.
+func (s *Service) GetDetail(ctx context.Context, symbol, ind, interval string) (*store.Reading, error) {
+	if ind == "" {
+		ind = DefaultIndicator
+	}
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	sym, err := s.resolveSymbol(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.store.LatestFor(ctx, sym, ind, interval)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNoReadings
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
 }
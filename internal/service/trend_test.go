@@ -0,0 +1,217 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/client/binancetest"
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+	"github.com/frederickmarvel/supernova/internal/service"
+	"github.com/frederickmarvel/supernova/internal/store"
+)
+
+// goldenSymbols serves each golden path under a symbol.
+var goldenSymbols = map[string]string{
+	"TRENDUSDT":  "trend",
+	"REVERTUSDT": "mean_revert",
+	"CRASHUSDT":  "crash",
+	"GAPUSDT":    "gap",
+}
+
+// goldenSlots are the update times checked: just after the crash candle
+// closed, and after the last candle closed.
+var goldenSlots = []time.Time{
+	binancetest.GoldenStart.Add(351 * time.Hour),
+	binancetest.GoldenEnd(),
+}
+
+func newService(t *testing.T) (*service.Service, *store.Memory) {
+	t.Helper()
+	srv := binancetest.NewServer()
+	t.Cleanup(srv.Close)
+	end := binancetest.GoldenEnd()
+	srv.Now = func() time.Time { return end }
+
+	var specs []indicator.Spec
+	for _, name := range indicator.Names() {
+		specs = append(specs, indicator.Spec{Name: name})
+	}
+	var syms []config.TrackedSymbol
+	for sym, name := range goldenSymbols {
+		klines, err := binancetest.GoldenKlines(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.SetKlines(sym, "1h", klines)
+		syms = append(syms, config.TrackedSymbol{Name: sym, Symbol: sym, Indicators: specs})
+	}
+
+	st := store.NewMemory()
+	svc := service.New(st, srv.BinanceClient(), nil)
+	if err := svc.InitSymbols(context.Background(), syms); err != nil {
+		t.Fatal(err)
+	}
+	return svc, st
+}
+
+func TestUpdateAtGolden(t *testing.T) {
+	svc, st := newService(t)
+	ctx := context.Background()
+	for _, at := range goldenSlots {
+		if err := svc.UpdateAt(ctx, "1h", at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// symbol@slot -> indicator -> output
+	got := make(map[string]map[string]map[string]float64)
+	for sym := range goldenSymbols {
+		for _, name := range indicator.Names() {
+			readings, err := st.Range(ctx, store.RangeQuery{
+				Symbol: sym, Indicator: name, Interval: "1h",
+				After: binancetest.GoldenStart, To: goldenSlots[len(goldenSlots)-1], Limit: 10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(readings) != len(goldenSlots) {
+				t.Fatalf("%s %s: %d readings, want one per slot", sym, name, len(readings))
+			}
+			for i, r := range readings {
+				if !r.Timestamp.Equal(goldenSlots[i]) {
+					t.Errorf("%s %s reading %d at %s, want %s", sym, name, i, r.Timestamp, goldenSlots[i])
+				}
+				k := sym + "@" + r.Timestamp.Format(time.RFC3339)
+				if got[k] == nil {
+					got[k] = make(map[string]map[string]float64)
+				}
+				got[k][name] = r.Detail
+			}
+		}
+	}
+	binancetest.Golden(t, "update_at.json", got)
+}
+
+// TestUpdateAtUsesClosedCandles checks that a slot in the middle of a
+// candle reads the same as the close before it.
+func TestUpdateAtUsesClosedCandles(t *testing.T) {
+	svc, st := newService(t)
+	ctx := context.Background()
+	at := goldenSlots[0]
+	if err := svc.UpdateAt(ctx, "1h", at); err != nil {
+		t.Fatal(err)
+	}
+	mid := at.Add(30 * time.Minute)
+	if err := svc.UpdateAt(ctx, "1h", mid); err != nil {
+		t.Fatal(err)
+	}
+	for sym := range goldenSymbols {
+		for _, name := range indicator.Names() {
+			readings, err := st.Range(ctx, store.RangeQuery{
+				Symbol: sym, Indicator: name, Interval: "1h",
+				After: at, Inclusive: true, To: mid, Limit: 10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(readings) != 2 || readings[0].Value != readings[1].Value || !readings[1].Timestamp.Equal(mid) {
+				t.Errorf("%s %s: readings %+v, want the same value twice", sym, name, readings)
+			}
+		}
+	}
+}
+
+// TestBackfillMatchesUpdateAt checks that a slot backfilled from a long
+// history reads the same as the live update of that slot, across the gap
+// as well.
+func TestBackfillMatchesUpdateAt(t *testing.T) {
+	live, liveStore := newService(t)
+	filled, filledStore := newService(t)
+	ctx := context.Background()
+	for _, at := range goldenSlots {
+		if err := live.UpdateAt(ctx, "1h", at); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := filled.Backfill(ctx, service.BackfillOptions{Interval: "1h", From: at.Add(-time.Minute), To: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for sym := range goldenSymbols {
+		for _, name := range indicator.Names() {
+			q := store.RangeQuery{
+				Symbol: sym, Indicator: name, Interval: "1h",
+				After: binancetest.GoldenStart, To: goldenSlots[len(goldenSlots)-1], Limit: 10,
+			}
+			want, err := liveStore.Range(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := filledStore.Range(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%s %s: %d backfilled readings, %d live", sym, name, len(got), len(want))
+			}
+			for i := range got {
+				if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Value != want[i].Value {
+					t.Errorf("%s %s: backfilled %v at %s, live %v at %s", sym, name,
+						got[i].Value, got[i].Timestamp, want[i].Value, want[i].Timestamp)
+				}
+			}
+		}
+	}
+}
+
+func TestInitSymbolsRejectsUnsupportedInterval(t *testing.T) {
+	saved := service.Intervals
+	t.Cleanup(func() { service.Intervals = saved })
+	service.Intervals = []string{"1h", "2h"}
+
+	svc := service.New(store.NewMemory(), nil, nil)
+	err := svc.InitSymbols(context.Background(), []config.TrackedSymbol{
+		{Name: "bitcoin", Symbol: "BTCUSDT"},
+		{Name: "bitcoin-idr", Symbol: "BTCIDR", Exchange: config.ExchangeIndodax},
+	})
+	if !errors.Is(err, service.ErrInvalidSymbol) {
+		t.Fatalf("indodax symbol with 2h: err = %v, want ErrInvalidSymbol", err)
+	}
+	syms, err := svc.ListSymbols(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 0 {
+		t.Errorf("registry seeded with %d symbols despite the error", len(syms))
+	}
+}
+
+// TestUpdateAtKeepsGoing checks that one broken symbol does not stop the
+// readings of the others from being saved.
+func TestUpdateAtKeepsGoing(t *testing.T) {
+	svc, st := newService(t)
+	ctx := context.Background()
+	if err := st.PutSymbol(ctx, config.TrackedSymbol{
+		Name: "broken", Symbol: "TRENDUSDT", Indicators: []indicator.Spec{{Name: "no_such_indicator"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	at := goldenSlots[0]
+	err := svc.UpdateAt(ctx, "1h", at)
+	if err == nil || !strings.Contains(err.Error(), "TRENDUSDT") {
+		t.Fatalf("err = %v, want the TRENDUSDT failure", err)
+	}
+	readings, err := st.Range(ctx, store.RangeQuery{
+		Symbol: "CRASHUSDT", Indicator: "ewma", Interval: "1h",
+		After: at, Inclusive: true, To: at, Limit: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 1 {
+		t.Errorf("CRASHUSDT: %d readings, want 1", len(readings))
+	}
+}
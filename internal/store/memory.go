@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+)
+
+type seriesKey struct {
+	symbol, indicator, interval string
+}
+
+// Memory is a Store kept entirely in process memory. It is safe for
+// concurrent use and loses everything on restart.
+type Memory struct {
+	mu      sync.RWMutex
+	symbols []config.TrackedSymbol
+	// each series is kept sorted by timestamp
+	series map[seriesKey][]Reading
+}
+
+func NewMemory() *Memory {
+	return &Memory{series: make(map[seriesKey][]Reading)}
+}
+
+func copySymbol(s config.TrackedSymbol) config.TrackedSymbol {
+	s.Indicators = append([]indicator.Spec(nil), s.Indicators...)
+	return s
+}
+
+func (m *Memory) ListSymbols(_ context.Context) ([]config.TrackedSymbol, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]config.TrackedSymbol, len(m.symbols))
+	for i, s := range m.symbols {
+		out[i] = copySymbol(s)
+	}
+	return out, nil
+}
+
+func (m *Memory) ReplaceSymbols(_ context.Context, syms []config.TrackedSymbol) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.symbols = m.symbols[:0]
+	for _, s := range syms {
+		m.symbols = append(m.symbols, copySymbol(s))
+	}
+	return nil
+}
+
+func (m *Memory) indexOf(symbol string) int {
+	for i, s := range m.symbols {
+		if s.Symbol == symbol {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Memory) PutSymbol(_ context.Context, s config.TrackedSymbol) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.indexOf(s.Symbol); i >= 0 {
+		m.symbols[i] = copySymbol(s)
+		return nil
+	}
+	m.symbols = append(m.symbols, copySymbol(s))
+	return nil
+}
+
+func (m *Memory) SetIndicators(_ context.Context, symbol string, specs []indicator.Spec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexOf(symbol)
+	if i < 0 {
+		return ErrSymbolNotFound
+	}
+	m.symbols[i].Indicators = append([]indicator.Spec(nil), specs...)
+	return nil
+}
+
+func (m *Memory) DeleteSymbol(_ context.Context, symbol string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexOf(symbol)
+	if i < 0 {
+		return ErrSymbolNotFound
+	}
+	m.symbols = append(m.symbols[:i], m.symbols[i+1:]...)
+	return nil
+}
+
+func (m *Memory) SaveReadings(_ context.Context, readings []Reading) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range readings {
+		k := seriesKey{r.Symbol, r.Indicator, r.Interval}
+		s := m.series[k]
+		i := sort.Search(len(s), func(i int) bool { return !s[i].Timestamp.Before(r.Timestamp) })
+		if i < len(s) && s[i].Timestamp.Equal(r.Timestamp) {
+			s[i] = r
+			continue
+		}
+		s = append(s, Reading{})
+		copy(s[i+1:], s[i:])
+		s[i] = r
+		m.series[k] = s
+	}
+	return nil
+}
+
+func (m *Memory) Latest(_ context.Context, ind, interval string) ([]Reading, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Reading
+	for _, sym := range m.symbols {
+		s := m.series[seriesKey{sym.Symbol, ind, interval}]
+		if len(s) > 0 {
+			out = append(out, s[len(s)-1])
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) LatestFor(_ context.Context, symbol, ind, interval string) (Reading, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := m.series[seriesKey{symbol, ind, interval}]
+	if len(s) == 0 {
+		return Reading{}, ErrNotFound
+	}
+	return s[len(s)-1], nil
+}
+
+func (m *Memory) Range(_ context.Context, q RangeQuery) ([]Reading, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := m.series[seriesKey{q.Symbol, q.Indicator, q.Interval}]
+	i := sort.Search(len(s), func(i int) bool {
+		if q.Inclusive {
+			return !s[i].Timestamp.Before(q.After)
+		}
+		return s[i].Timestamp.After(q.After)
+	})
+	var out []Reading
+	for ; i < len(s) && len(out) < q.Limit; i++ {
+		if s[i].Timestamp.After(q.To) {
+			break
+		}
+		out = append(out, s[i])
+	}
+	return out, nil
+}
+
+func (m *Memory) LastTimestamp(_ context.Context, interval string, keep func(time.Time) bool) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var last time.Time
+	for k, s := range m.series {
+		if k.interval != interval {
+			continue
+		}
+		for i := len(s) - 1; i >= 0 && s[i].Timestamp.After(last); i-- {
+			if keep == nil || keep(s[i].Timestamp) {
+				last = s[i].Timestamp
+				break
+			}
+		}
+	}
+	return last, nil
+}
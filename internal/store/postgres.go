@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+)
+
+// Postgres stores symbols in tracked_symbols and readings in trend_readings,
+// both created by the migrate package.
+type Postgres struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) ListSymbols(ctx context.Context) ([]config.TrackedSymbol, error) {
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT name, symbol, exchange, indicators FROM tracked_symbols ORDER BY created_at, symbol`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []config.TrackedSymbol
+	for rows.Next() {
+		var s config.TrackedSymbol
+		var inds []byte
+		if err := rows.Scan(&s.Name, &s.Symbol, &s.Exchange, &inds); err != nil {
+			return nil, err
+		}
+		if len(inds) > 0 {
+			if err := json.Unmarshal(inds, &s.Indicators); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// marshalIndicators encodes specs for the indicators column; NULL means
+// "use the defaults".
+func marshalIndicators(specs []indicator.Spec) ([]byte, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(specs)
+}
+
+func exchange(s config.TrackedSymbol) string {
+	if s.Exchange == "" {
+		return config.ExchangeBinance
+	}
+	return s.Exchange
+}
+
+func (p *Postgres) ReplaceSymbols(ctx context.Context, syms []config.TrackedSymbol) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tracked_symbols`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, s := range syms {
+		inds, err := marshalIndicators(s.Indicators)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO tracked_symbols (symbol, name, exchange, indicators) VALUES ($1, $2, $3, $4)`,
+			s.Symbol, s.Name, exchange(s), inds,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) PutSymbol(ctx context.Context, s config.TrackedSymbol) error {
+	inds, err := marshalIndicators(s.Indicators)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx,
+		`INSERT INTO tracked_symbols (symbol, name, exchange, indicators) VALUES ($1, $2, $3, $4)
+         ON CONFLICT (symbol) DO UPDATE
+         SET name = EXCLUDED.name, exchange = EXCLUDED.exchange, indicators = EXCLUDED.indicators`,
+		s.Symbol, s.Name, exchange(s), inds,
+	)
+	return err
+}
+
+func (p *Postgres) SetIndicators(ctx context.Context, symbol string, specs []indicator.Spec) error {
+	inds, err := marshalIndicators(specs)
+	if err != nil {
+		return err
+	}
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE tracked_symbols SET indicators = $2 WHERE symbol = $1`, symbol, inds,
+	)
+	return affected(res, err)
+}
+
+func (p *Postgres) DeleteSymbol(ctx context.Context, symbol string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM tracked_symbols WHERE symbol = $1`, symbol)
+	return affected(res, err)
+}
+
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSymbolNotFound
+	}
+	return nil
+}
+
+func (p *Postgres) SaveReadings(ctx context.Context, readings []Reading) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, r := range readings {
+		detail, err := json.Marshal(r.Detail)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		cfg, err := json.Marshal(r.Config)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO trend_readings (symbol, indicator, interval, value, detail, config, timestamp)
+             VALUES ($1,$2,$3,$4,$5,$6,$7)
+             ON CONFLICT (symbol, indicator, interval, timestamp)
+             DO UPDATE SET value = EXCLUDED.value, detail = EXCLUDED.detail, config = EXCLUDED.config`,
+			r.Symbol, r.Indicator, r.Interval, r.Value, detail, cfg, r.Timestamp.UTC(),
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const readingColumns = `r.symbol, r.indicator, r.interval, r.value, r.detail, r.config, r.timestamp`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReading(s scanner) (Reading, error) {
+	var r Reading
+	var detail, cfg []byte
+	if err := s.Scan(&r.Symbol, &r.Indicator, &r.Interval, &r.Value, &detail, &cfg, &r.Timestamp); err != nil {
+		return r, err
+	}
+	r.Timestamp = r.Timestamp.UTC()
+	if len(detail) > 0 {
+		if err := json.Unmarshal(detail, &r.Detail); err != nil {
+			return r, err
+		}
+	}
+	if len(cfg) > 0 && string(cfg) != "null" {
+		r.Config = new(indicator.Spec)
+		if err := json.Unmarshal(cfg, r.Config); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func scanReadings(rows *sql.Rows, err error) ([]Reading, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Reading
+	for rows.Next() {
+		r, err := scanReading(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (p *Postgres) Latest(ctx context.Context, ind, interval string) ([]Reading, error) {
+	return scanReadings(p.db.QueryContext(ctx,
+		`SELECT DISTINCT ON (r.symbol) `+readingColumns+`
+         FROM trend_readings r
+         JOIN tracked_symbols s ON s.symbol = r.symbol
+         WHERE r.indicator = $1 AND r.interval = $2
+         ORDER BY r.symbol, r.timestamp DESC`,
+		ind, interval,
+	))
+}
+
+func (p *Postgres) LatestFor(ctx context.Context, symbol, ind, interval string) (Reading, error) {
+	r, err := scanReading(p.db.QueryRowContext(ctx,
+		`SELECT `+readingColumns+` FROM trend_readings r
+         WHERE r.symbol = $1 AND r.indicator = $2 AND r.interval = $3
+         ORDER BY r.timestamp DESC LIMIT 1`,
+		symbol, ind, interval,
+	))
+	if err == sql.ErrNoRows {
+		return r, ErrNotFound
+	}
+	return r, err
+}
+
+func (p *Postgres) Range(ctx context.Context, q RangeQuery) ([]Reading, error) {
+	op := ">"
+	if q.Inclusive {
+		op = ">="
+	}
+	return scanReadings(p.db.QueryContext(ctx,
+		`SELECT `+readingColumns+` FROM trend_readings r
+         WHERE r.symbol = $1 AND r.indicator = $2 AND r.interval = $3
+           AND r.timestamp `+op+` $4 AND r.timestamp <= $5
+         ORDER BY r.timestamp ASC LIMIT $6`,
+		q.Symbol, q.Indicator, q.Interval, q.After.UTC(), q.To.UTC(), q.Limit,
+	))
+}
+
+func (p *Postgres) LastTimestamp(ctx context.Context, interval string, keep func(time.Time) bool) (time.Time, error) {
+	if keep == nil {
+		var ts sql.NullTime
+		err := p.db.QueryRowContext(ctx,
+			`SELECT max(timestamp) FROM trend_readings WHERE interval = $1`, interval,
+		).Scan(&ts)
+		return ts.Time.UTC(), err
+	}
+	// newest first, so the scan usually stops at the first row
+	rows, err := p.db.QueryContext(ctx,
+		`SELECT DISTINCT timestamp FROM trend_readings WHERE interval = $1 ORDER BY timestamp DESC`, interval,
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ts time.Time
+		if err := rows.Scan(&ts); err != nil {
+			return time.Time{}, err
+		}
+		if ts = ts.UTC(); keep(ts) {
+			return ts, nil
+		}
+	}
+	return time.Time{}, rows.Err()
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/frederickmarvel/supernova/internal/config"
+	"github.com/frederickmarvel/supernova/internal/indicator"
+)
+
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrSymbolNotFound = errors.New("symbol not registered")
+)
+
+type Reading struct {
+	Symbol    string             `json:"symbol"`
+	Indicator string             `json:"indicator"`
+	Interval  string             `json:"interval"`
+	Value     float64            `json:"value"`
+	Detail    map[string]float64 `json:"detail,omitempty"`
+	Config    *indicator.Spec    `json:"config,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// RangeQuery selects readings of one series with a timestamp after After
+// (or at it, when Inclusive) and no later than To, oldest first.
+type RangeQuery struct {
+	Symbol    string
+	Indicator string
+	Interval  string
+	After     time.Time
+	Inclusive bool
+	To        time.Time
+	Limit     int
+}
+
+// SymbolStore is the registry of tracked symbols.
+type SymbolStore interface {
+	ListSymbols(ctx context.Context) ([]config.TrackedSymbol, error)
+	// ReplaceSymbols makes syms the whole registry.
+	ReplaceSymbols(ctx context.Context, syms []config.TrackedSymbol) error
+	// PutSymbol inserts a symbol or updates its name and indicators.
+	PutSymbol(ctx context.Context, s config.TrackedSymbol) error
+	SetIndicators(ctx context.Context, symbol string, specs []indicator.Spec) error
+	DeleteSymbol(ctx context.Context, symbol string) error
+}
+
+// TrendStore holds computed readings. A reading is identified by symbol,
+// indicator, interval and timestamp; saving the same key again replaces it.
+type TrendStore interface {
+	// SaveReadings upserts all readings atomically.
+	SaveReadings(ctx context.Context, readings []Reading) error
+	// Latest returns the newest reading of indicator and interval for each
+	// registered symbol that has one.
+	Latest(ctx context.Context, indicator, interval string) ([]Reading, error)
+	// LatestFor returns the newest reading of one series or ErrNotFound.
+	LatestFor(ctx context.Context, symbol, indicator, interval string) (Reading, error)
+	Range(ctx context.Context, q RangeQuery) ([]Reading, error)
+	// LastTimestamp is the newest reading time for interval that keep
+	// accepts, or zero. A nil keep accepts every time.
+	LastTimestamp(ctx context.Context, interval string, keep func(time.Time) bool) (time.Time, error)
+}
+
+type Store interface {
+	SymbolStore
+	TrendStore
+}